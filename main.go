@@ -18,13 +18,20 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/nerdzeu/nerdz-api/nerdz"
 	"github.com/nerdzeu/nerdz-api/router"
+	"github.com/nerdzeu/nerdz-api/stream"
 	"github.com/rs/cors"
 )
 
@@ -35,8 +42,37 @@ func main() {
 	r.Use(echo.WrapMiddleware(cors.New(cors.Options{}).Handler))
 	// Recover from panics
 	r.Use(middleware.Recover())
+	// Deliver the webhooks in background, retrying the failed deliveries every minute
+	webhooksCtx, stopWebhooks := context.WithCancel(context.Background())
+	go nerdz.DeliverWebhooks(webhooksCtx, time.Minute)
+	// Publish the users that go offline, checking every minute
+	presenceCtx, stopPresence := context.WithCancel(context.Background())
+	go nerdz.TrackPresence(presenceCtx, time.Minute)
+	// Publish the scheduled posts, checking every minute
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	go nerdz.PublishScheduledPosts(schedulerCtx, time.Minute)
 	// Start the router
-	if err := r.Start(":" + strconv.Itoa(int(nerdz.Configuration.Port))); err != nil {
-		log.Fatal(err)
+	go func() {
+		if err := r.Start(":" + strconv.Itoa(int(nerdz.Configuration.Port))); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Wait for SIGINT or SIGTERM, then stop accepting new connections
+	// and drain the open streams (hijacked connections are not handled by Shutdown)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	stopWebhooks()
+	stopPresence()
+	stopScheduler()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		log.Println(err)
+	}
+	if err := stream.Shutdown(ctx); err != nil {
+		log.Println(err)
 	}
 }
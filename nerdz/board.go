@@ -52,6 +52,7 @@ type PostlistOptions struct {
 	OlderModel igor.DBModel // igor.DBModel required when the older identifier is fetched from a view
 	Newer      uint64       // if specified, tells to the function using this struct to return N posts NEWER (created after) the post with the specified "Newer" ID
 	NewerModel igor.DBModel // igor.DBModel required when the newer identifier is fetched from a view
+	Viewer     *User        // if specified, the board posts are returned only if the board is visible by the Viewer
 }
 
 // CommentlistOptions is used to specify the options for a list of comments
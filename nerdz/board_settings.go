@@ -0,0 +1,102 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import "github.com/nerdzeu/nerdz-api/utils"
+
+// BoardSettings are the rules of access to a user board. The owner and the whitelisted users
+// are never restricted
+type BoardSettings struct {
+	// Closed is true when the other users can't write posts on the board
+	Closed bool
+	// Private is true when the other users can't see the board
+	Private bool
+	// WhitelistOnly is true when the other users can't write posts and comments on the board
+	WhitelistOnly bool
+	// Whitelist are the IDs of the whitelisted users
+	Whitelist []uint64
+}
+
+// GetTO returns its Transfer Object
+func (s *BoardSettings) GetTO(users ...*User) *BoardSettingsTO {
+	to := &BoardSettingsTO{
+		original:      s,
+		Closed:        s.Closed,
+		Private:       s.Private,
+		WhitelistOnly: s.WhitelistOnly,
+	}
+	for _, user := range Users(s.Whitelist) {
+		to.Whitelist = append(to.Whitelist, user.Info().GetTO())
+	}
+	return to
+}
+
+// privateBoardVisibility is the condition on the user board "to" of the message "t" to be visible by the user:
+// the board is not private, or the user is its owner or one of its whitelisted users. Every placeholder is the user ID
+const privateBoardVisibility = `(t."to" = ?
+	OR t."to" NOT IN (SELECT counter FROM users WHERE private)
+	OR ? IN (SELECT w."to" FROM whitelist w WHERE w."from" = t."to"))`
+
+// BoardSettings returns the rules of access to the user board
+func (user *User) BoardSettings() *BoardSettings {
+	var whitelist []uint64
+	_ = Db().Model(Whitelist{}).Where(Whitelist{From: user.ID()}).Pluck(`"to"`, &whitelist)
+	return &BoardSettings{
+		Closed:        user.Profile.Closed,
+		Private:       user.Private,
+		WhitelistOnly: user.Profile.WhitelistOnly,
+		Whitelist:     whitelist,
+	}
+}
+
+// UpdateBoardSettings stores the rules of access to the user board.
+// The whitelist of the settings is ignored: it's managed with WhitelistUser and UnwhitelistUser
+func (user *User) UpdateBoardSettings(settings *BoardSettings) error {
+	tx := Db().Begin()
+	if err := tx.Exec(`UPDATE users SET private = ? WHERE counter = ?`, settings.Private, user.ID()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Exec(`UPDATE profiles SET closed = ?, whitelist_only = ? WHERE counter = ?`,
+		settings.Closed, settings.WhitelistOnly, user.ID()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	user.Private = settings.Private
+	user.Profile.Closed = settings.Closed
+	user.Profile.WhitelistOnly = settings.WhitelistOnly
+	return nil
+}
+
+// CanWrite returns true if the user can write posts on the board of the other user
+func (user *User) CanWrite(other *User) bool {
+	if user.ID() == other.ID() {
+		return true
+	}
+	if !user.CanSee(other) {
+		return false
+	}
+	if other.Profile.Closed || other.Profile.WhitelistOnly {
+		return utils.InSlice(user.ID(), other.NumericWhitelist())
+	}
+	return true
+}
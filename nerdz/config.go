@@ -46,6 +46,8 @@ type Config struct {
 	Port       int16 // API port, optional -> default: 7536
 	Host       string
 	Scheme     string
+	// StreamsPerUser is the maximum number of streams a user can open at the same time
+	StreamsPerUser int // optional -> default: 5
 }
 
 // Configuration represent the parsed configuration file
@@ -64,7 +66,9 @@ var scopes = []string{
 	"friends",
 	"profile_comments",
 	"project_comments",
-	"base", // access to every scope above
+	"apps",     // the OAuth2 applications of the user and their webhooks
+	"contacts", // the contact info of the followers and members of the projects owned by the user
+	"base",     // access to every scope above
 }
 
 // initConfiguration initialize the API parsing the configuration file
@@ -118,6 +122,10 @@ func initConfiguration(path string) error {
 		Configuration.Port = 7536
 	}
 
+	if Configuration.StreamsPerUser <= 0 {
+		Configuration.StreamsPerUser = 5
+	}
+
 	if Configuration.NERDZHost == "" {
 		return errors.New("NERDZHost is a required field")
 	}
@@ -0,0 +1,92 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+)
+
+// DirectoryOptions represent the configuration used to search users (by username) and projects (by name)
+type DirectoryOptions struct {
+	Query        string // the names starting with the query, or similar to the query, match
+	Autocomplete bool   // if true, only the names starting with the query match
+	Exact        bool   // if true, only the name equal (case insensitive) to the query matches
+	N            uint8  // number of results to return
+}
+
+// likeEscaper escapes the LIKE special characters
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// directorySearch returns the counters of the rows of table, visible according to the visibility condition,
+// whose column matches the options. The names starting with the query come first, then the most similar names
+func directorySearch(table, column, visibility string, visibilityArgs []interface{}, options DirectoryOptions) []uint64 {
+	value := strings.ToLower(strings.TrimSpace(options.Query))
+	if value == "" {
+		return nil
+	}
+	prefix := likeEscaper.Replace(value) + "%"
+	name := "LOWER(" + column + ")"
+
+	var match string
+	var args []interface{}
+	switch {
+	case options.Exact:
+		match, args = name+" = ?", []interface{}{value}
+	case options.Autocomplete:
+		match, args = name+" LIKE ?", []interface{}{prefix}
+	default:
+		// % is the similarity operator of pg_trgm
+		match, args = "("+name+" LIKE ? OR "+name+" % ?)", []interface{}{prefix, value}
+	}
+	args = append(args, visibilityArgs...)
+	args = append(args, prefix, value)
+
+	query := fmt.Sprintf(`SELECT counter FROM %s WHERE %s AND %s
+	ORDER BY %s LIKE ? DESC, similarity(%s, ?) DESC, %s
+	LIMIT %d`, table, match, visibility, name, name, name, AtMostUsers(uint64(options.N)))
+
+	var counters []uint64
+	if err := Db().Raw(query, args...).Scan(&counters); err != nil && err != sql.ErrNoRows {
+		log.Errorf("(directorySearch) Error in query.Scan: %s", err)
+	}
+	return counters
+}
+
+// SearchUsers returns the users whose username matches the options.
+// The users that the current user can't see are not returned: the ones that put the current user
+// in their blacklist and the private ones that didn't put the current user in their whitelist
+func (user *User) SearchUsers(options DirectoryOptions) []*User {
+	return Users(directorySearch(User{}.TableName(), "username",
+		`counter NOT IN (SELECT "from" FROM blacklist WHERE "to" = ?)
+		AND (NOT private OR counter = ? OR counter IN (SELECT "from" FROM whitelist WHERE "to" = ?))`,
+		[]interface{}{user.ID(), user.ID(), user.ID()}, options))
+}
+
+// SearchProjects returns the projects whose name matches the options.
+// The invisible projects are returned only to their owner and members
+func (user *User) SearchProjects(options DirectoryOptions) []*Project {
+	return Projects(directorySearch(Project{}.TableName(), "name",
+		`(visible OR ? IN (
+			SELECT "from" FROM groups_members WHERE "to" = groups.counter
+			UNION ALL
+			SELECT "from" FROM groups_owners WHERE "to" = groups.counter))`, []interface{}{user.ID()}, options))
+}
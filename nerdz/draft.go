@@ -0,0 +1,228 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// draftPublishing is the error of the scheduled posts claimed by the scheduler:
+// it remains only if the publication is interrupted before completing
+const draftPublishing = "the publication of the scheduled post has been interrupted"
+
+// errDraftGone is returned when the draft has been published or deleted in the meantime
+var errDraftGone = errors.New("the draft has already been published or deleted")
+
+// NewDraft returns the draft identified by id
+func NewDraft(id uint64) (*Draft, error) {
+	if id == 0 {
+		return nil, errors.New("requested draft does not exist")
+	}
+	draft := new(Draft)
+	if err := Db().First(draft, id); err != nil {
+		return nil, err
+	}
+	if draft.ID == 0 {
+		return nil, errors.New("requested draft does not exist")
+	}
+	return draft, nil
+}
+
+// Drafts returns the drafts and the scheduled posts of the user, from the last updated
+func (user *User) Drafts() (drafts []Draft) {
+	_ = Db().Model(Draft{}).Where(&Draft{From: user.ID()}).Order("updated_at DESC, id DESC").Scan(&drafts)
+	return
+}
+
+// validateDraft returns an error if the user can't write the draft
+func (user *User) validateDraft(draft *Draft) error {
+	draft.Message = strings.TrimSpace(draft.Message)
+	if draft.Message == "" {
+		return errors.New("the message of the draft must be not empty")
+	}
+	if draft.Lang != "" && !utils.InSlice(draft.Lang, Configuration.Languages) {
+		return errors.New("invalid language: " + draft.Lang)
+	}
+	if draft.PublishAt.Valid {
+		if !draft.PublishAt.Time.After(time.Now()) {
+			return errors.New("the publication time of a scheduled post must be in the future")
+		}
+		draft.PublishAt.Time = draft.PublishAt.Time.UTC()
+	}
+
+	switch draft.Type {
+	case UserBoardID:
+		if board, err := NewUser(draft.To); err != nil || !user.CanWrite(board) {
+			return errors.New("you can't write posts on this board")
+		}
+	case ProjectBoardID:
+		if board, err := NewProject(draft.To); err != nil || !user.CanSee(board) {
+			return errors.New("you can't write posts on this board")
+		}
+	default:
+		return errors.New("invalid board type: " + string(draft.Type))
+	}
+	return nil
+}
+
+// SaveDraft saves a new draft of the user, or a new scheduled post when PublishAt is valid.
+// The board and the message are validated again at the publication
+func (user *User) SaveDraft(draft *Draft) error {
+	if err := user.validateDraft(draft); err != nil {
+		return err
+	}
+	draft.From = user.ID()
+	draft.Error = ""
+	return Db().Create(draft)
+}
+
+// UpdateDraft updates the message, the language and the publication time of the draft.
+// The board of the draft can't be changed. The update fails if the draft has been published or deleted
+func (user *User) UpdateDraft(draft *Draft) error {
+	if draft.From != user.ID() {
+		return errors.New("you can't update the drafts of the other users")
+	}
+	if err := user.validateDraft(draft); err != nil {
+		return err
+	}
+	draft.Error = ""
+	draft.UpdatedAt = time.Now().UTC()
+
+	var id uint64
+	if err := Db().Raw(`UPDATE `+draft.TableName()+` SET message = ?, lang = ?, publish_at = ?, error = '', updated_at = ? WHERE id = ?
+	RETURNING id`, draft.Message, draft.Lang, draft.PublishAt, draft.UpdatedAt, draft.ID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return errDraftGone
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteDraft deletes the draft
+func (user *User) DeleteDraft(draft *Draft) error {
+	if draft.From != user.ID() {
+		return errors.New("you can't delete the drafts of the other users")
+	}
+	return Db().Delete(draft)
+}
+
+// PublishDraft publishes the draft on its board, with the same validation and notifications
+// of a new post, and deletes it. The draft is claimed deleting it before the publication, thus it's published once
+// even if it's published by the scheduler at the same time. The draft is restored when the publication fails
+func (user *User) PublishDraft(draft *Draft) (ExistingPost, error) {
+	if draft.From != user.ID() {
+		return nil, errors.New("you can't publish the drafts of the other users")
+	}
+
+	claimed := new(Draft)
+	if err := Db().Raw(`DELETE FROM `+draft.TableName()+` WHERE id = ? AND "from" = ?
+	RETURNING id, "from", "to", type, message, lang, publish_at, error, created_at, updated_at`, draft.ID, user.ID()).Scan(claimed); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errDraftGone
+		}
+		return nil, err
+	}
+
+	post, err := user.publish(claimed)
+	if err != nil {
+		if e := Db().Exec(`INSERT INTO `+claimed.TableName()+` (id, "from", "to", type, message, lang, publish_at, error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, claimed.ID, claimed.From, claimed.To, claimed.Type, claimed.Message, claimed.Lang,
+			claimed.PublishAt, claimed.Error, claimed.CreatedAt, claimed.UpdatedAt); e != nil {
+			log.Errorf("(PublishDraft) Error while restoring the draft %d: %s", claimed.ID, e)
+		}
+		return nil, err
+	}
+	return post, nil
+}
+
+// publish adds the post written in the draft to its board
+func (user *User) publish(draft *Draft) (ExistingPost, error) {
+	switch draft.Type {
+	case UserBoardID:
+		userPost := UserPost{}
+		userPost.To = draft.To
+		userPost.Message = draft.Message
+		userPost.Lang = draft.Lang
+		if err := user.Add(&userPost); err != nil {
+			return nil, err
+		}
+		return &userPost, nil
+	case ProjectBoardID:
+		projectPost := ProjectPost{}
+		projectPost.To = draft.To
+		projectPost.Message = draft.Message
+		projectPost.Lang = draft.Lang
+		if err := user.Add(&projectPost); err != nil {
+			return nil, err
+		}
+		return &projectPost, nil
+	}
+	return nil, errors.New("invalid board type: " + string(draft.Type))
+}
+
+// PublishScheduledPosts publishes the scheduled posts whose publication time is past, checking every period,
+// until the context is done. Every scheduled post is claimed before being published, thus it's published once
+// even if more instances are running: when the publication fails, the post goes back to the drafts with the error
+func PublishScheduledPosts(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var ids []uint64
+		if err := Db().Raw(`UPDATE `+Draft{}.TableName()+` SET publish_at = NULL, error = ?
+		WHERE id IN (
+			SELECT id FROM `+Draft{}.TableName()+`
+			WHERE publish_at <= (now() at time zone 'utc')
+			ORDER BY publish_at
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id`, draftPublishing).Scan(&ids); err != nil && err != sql.ErrNoRows {
+			log.Errorf("(PublishScheduledPosts) Error in query.Scan: %s", err)
+			continue
+		}
+
+		for _, id := range ids {
+			draft, err := NewDraft(id)
+			if err != nil {
+				continue
+			}
+			var author *User
+			if author, err = NewUser(draft.From); err == nil {
+				_, err = author.PublishDraft(draft)
+			}
+			if err != nil {
+				if e := Db().Exec(`UPDATE `+draft.TableName()+` SET error = ? WHERE id = ?`, err.Error(), draft.ID); e != nil {
+					log.Errorf("(PublishScheduledPosts) Error while saving the error of draft %d: %s", draft.ID, e)
+				}
+			}
+		}
+	}
+}
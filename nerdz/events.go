@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Database channels used to notify events.
+// The payload sent on the posts and comments channels is the ID of the new message,
+// the payload sent on the presence channel is the ID of the user,
+// the payload sent on the following channels is the ID of the user followed or unfollowed,
+// the payload sent on the pms channels is a JSON encoded PmEvent.
+
+// NotificationsChannel returns the channel where the notifications for the user are sent
+func NotificationsChannel(user uint64) string {
+	return "u" + strconv.FormatUint(user, 10)
+}
+
+// UserPostsChannel returns the channel where the new posts on the user board are sent
+func UserPostsChannel(user uint64) string {
+	return "user:" + strconv.FormatUint(user, 10) + ":posts"
+}
+
+// FollowingChannel returns the channel where the users followed or unfollowed by the user are sent
+func FollowingChannel(user uint64) string {
+	return "user:" + strconv.FormatUint(user, 10) + ":following"
+}
+
+// ProjectPostsChannel returns the channel where the new posts on the project board are sent
+func ProjectPostsChannel(project uint64) string {
+	return "project:" + strconv.FormatUint(project, 10) + ":posts"
+}
+
+// UserPostCommentsChannel returns the channel where the new comments on the user post are sent
+func UserPostCommentsChannel(hpid uint64) string {
+	return "post:" + strconv.FormatUint(hpid, 10) + ":comments"
+}
+
+// ProjectPostCommentsChannel returns the channel where the new comments on the project post are sent
+func ProjectPostCommentsChannel(hpid uint64) string {
+	return "project_post:" + strconv.FormatUint(hpid, 10) + ":comments"
+}
+
+// PmsChannel returns the channel where the new pms of the conversation between the two users are sent.
+// The channel is the same, no matter the order of the users
+func PmsChannel(user, other uint64) string {
+	if user > other {
+		user, other = other, user
+	}
+	return "pm:" + strconv.FormatUint(user, 10) + ":" + strconv.FormatUint(other, 10)
+}
+
+// PresenceChannel is the channel where the users that come online or go offline are sent
+const PresenceChannel = "presence"
+
+// Types of the events of a conversation
+const (
+	// PmEventNew is sent when a new pm is added to the conversation
+	PmEventNew = "pm"
+	// PmEventTyping is sent when a user is typing a pm. It's not persisted
+	PmEventTyping = "typing"
+	// PmEventRead is sent when a user reads the pms of the conversation, or the pm with Pmid
+	PmEventRead = "read"
+	// PmEventEdit is sent when the sender edits the pm
+	PmEventEdit = "edit"
+)
+
+// PmEvent is an event of the conversation between From and To, generated by From
+type PmEvent struct {
+	Type string    `json:"type"`
+	From uint64    `json:"from"`
+	To   uint64    `json:"to"`
+	Pmid uint64    `json:"pmid,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// notifyPmEvent sends the event on the channel of the conversation
+func notifyPmEvent(event *PmEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return Db().Notify(PmsChannel(event.From, event.To), string(payload))
+}
+
+// notifyEvent sends the ID on the database channel.
+// A failure is logged and not returned, since the event is not part of the action
+func notifyEvent(channel string, id uint64) {
+	if err := Db().Notify(channel, strconv.FormatUint(id, 10)); err != nil {
+		log.Errorf("Unable to notify %d on channel %s: %s", id, channel, err.Error())
+	}
+}
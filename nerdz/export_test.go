@@ -0,0 +1,29 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+// AllowPrivateWebhooks lets the webhooks point to non public addresses,
+// thus the tests can deliver them to the local test servers
+func AllowPrivateWebhooks(allow bool) {
+	webhookAllowPrivate = allow
+}
+
+// WaitWebhookTriggers waits for the enqueued webhook triggers to create their deliveries
+func WaitWebhookTriggers() {
+	webhookTriggersPending.Wait()
+}
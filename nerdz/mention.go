@@ -0,0 +1,97 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MinMentions represents the minimum mentions number that can be required in a list of mentions
+	MinMentions uint64 = 1
+	// MaxMentions represents the maximum mentions number that can be required in a list of mentions
+	MaxMentions uint64 = 20
+)
+
+// MentionsOptions is used to specify the options for a list of mentions
+type MentionsOptions struct {
+	Unread bool   // if true, return only the mentions not yet notified
+	N      uint8  // number of mentions to return
+	Older  uint64 // if specified, tells to the function that is using this struct to return N mentions OLDER (created before) than the mention with the specified "Older" ID
+	Newer  uint64 // if specified, tells to the function that is using this struct to return N mentions NEWER (created after) than the mention with the specified "Newer" ID
+}
+
+// Mentions returns the mentions of the user in the posts and in the comments,
+// from the most recent. The mentions made by the blacklisted users and the ones in the posts
+// of boards the user can't see are not returned
+func (user *User) Mentions(options MentionsOptions) (*[]Mention, error) {
+	// u_hpid and g_hpid are mutually exclusive, thus nullable
+	query := `SELECT id, COALESCE(u_hpid, 0), COALESCE(g_hpid, 0), "from", "to", "time", to_notify
+	FROM ` + Mention{}.TableName() + `
+	WHERE "to" = ? AND "from" NOT IN (SELECT "to" FROM blacklist WHERE "from" = ?)
+	AND (u_hpid IS NULL OR u_hpid IN (SELECT t.hpid FROM posts t WHERE ` + userBoardVisibility + `))
+	AND (g_hpid IS NULL OR g_hpid IN (SELECT t.hpid FROM groups_posts t WHERE ` + projectBoardVisibility + `))`
+	args := []interface{}{user.ID(), user.ID()}
+	for i := strings.Count(userBoardVisibility+projectBoardVisibility, "?"); i > 0; i-- {
+		args = append(args, user.ID())
+	}
+
+	if options.Unread {
+		query += ` AND to_notify`
+	}
+	if options.Older != 0 && options.Newer != 0 {
+		query += ` AND id BETWEEN ? AND ?`
+		args = append(args, options.Newer, options.Older)
+	} else if options.Older != 0 {
+		query += ` AND id < ?`
+		args = append(args, options.Older)
+	} else if options.Newer != 0 {
+		query += ` AND id > ?`
+		args = append(args, options.Newer)
+	}
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT %d`, AtMostMentions(uint64(options.N)))
+
+	var mentions []Mention
+	if err := Db().Raw(query, args...).Scan(&mentions); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &mentions, nil
+}
+
+// Board returns the board of the post that contains the mention
+func (m *Mention) Board() (Board, error) {
+	if m.UHpid != 0 {
+		post, err := NewUserPost(m.UHpid)
+		if err != nil {
+			return nil, err
+		}
+		return NewUser(post.To)
+	}
+	post, err := NewProjectPost(m.GHpid)
+	if err != nil {
+		return nil, err
+	}
+	return NewProject(post.To)
+}
+
+// ReadMentions marks as notified every mention of the user
+func (user *User) ReadMentions() error {
+	return Db().Exec(`UPDATE `+Mention{}.TableName()+` SET to_notify = FALSE WHERE "to" = ? AND to_notify`, user.ID())
+}
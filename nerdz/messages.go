@@ -18,16 +18,18 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package nerdz
 
 import (
+	"fmt"
 	"html"
 	"net/url"
 
 	"github.com/galeone/igor"
+	"github.com/nerdzeu/nerdz-api/utils"
 )
 
 // Type definitions for [comment, post, pm]
 
 // newMessage is the interface that wraps methods common to every new mesage
-// Implementations: (UserPost, ProjectPost, UserPostComment, ProjectPostComment, Pm)
+// Implementations: (UserPost, ProjectPost, UserPostComment, ProjectPostComment, Pm, PmGroupMessage)
 type newMessage interface {
 	SetSender(uint64)
 	SetReference(uint64)
@@ -111,6 +113,8 @@ type ExistingPost interface {
 	Lurks() *[]Lurk
 	URL() *url.URL
 	IsClosed() bool
+	NumericCloser() uint64
+	IsPinned() bool
 	NumericType() uint8
 	Type() string
 }
@@ -136,3 +140,9 @@ func createMessage(message newMessage, sender, reference uint64, text, language
 func updateMessage(message editingMessage) error {
 	return createMessage(message, message.NumericSender(), message.NumericReference(), message.Text(), message.Language())
 }
+
+// RevisionDiff returns the unified diff of the revision revNo of a message
+// against the previous revision
+func RevisionDiff(previous, revision string, revNo int) string {
+	return utils.UnifiedDiff(previous, revision, fmt.Sprintf("revision %d", revNo-1), fmt.Sprintf("revision %d", revNo))
+}
@@ -0,0 +1,37 @@
+-- Webhooks registered by the OAuth2 clients, their delivery log and the dead letters.
+
+CREATE TABLE oauth2_webhooks(
+    id bigserial NOT NULL PRIMARY KEY,
+    client_id bigint NOT NULL REFERENCES oauth2_clients(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    event varchar(20) NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    UNIQUE(client_id, url, event)
+);
+
+CREATE TABLE oauth2_webhook_deliveries(
+    id bigserial NOT NULL PRIMARY KEY,
+    webhook_id bigint NOT NULL REFERENCES oauth2_webhooks(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    event varchar(20) NOT NULL,
+    payload text NOT NULL,
+    status varchar(10) NOT NULL DEFAULT 'pending',
+    attempts smallint NOT NULL DEFAULT 0,
+    status_code integer NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    next_attempt_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+CREATE INDEX oauth2_webhook_deliveries_pending_idx ON oauth2_webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE oauth2_webhook_dead_letters(
+    id bigserial NOT NULL PRIMARY KEY,
+    delivery_id bigint NOT NULL REFERENCES oauth2_webhook_deliveries(id) ON DELETE CASCADE,
+    webhook_id bigint NOT NULL REFERENCES oauth2_webhooks(id) ON DELETE CASCADE,
+    event varchar(20) NOT NULL,
+    payload text NOT NULL,
+    attempts smallint NOT NULL,
+    last_error text NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc')
+);
@@ -0,0 +1,23 @@
+-- Revisions of the edited pms: the previous message is stored every time the message changes.
+
+CREATE TABLE pms_revisions(
+    counter bigserial NOT NULL PRIMARY KEY,
+    pmid bigint NOT NULL REFERENCES pms(pmid) ON DELETE CASCADE,
+    message text NOT NULL,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    rev_no integer NOT NULL,
+    UNIQUE(pmid, rev_no)
+);
+
+CREATE FUNCTION pm_revision() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF OLD.message <> NEW.message THEN
+        INSERT INTO pms_revisions(pmid, message, rev_no)
+        VALUES(OLD.pmid, OLD.message, (SELECT COUNT(*) + 1 FROM pms_revisions WHERE pmid = OLD.pmid));
+    END IF;
+    RETURN NEW;
+END $$;
+
+CREATE TRIGGER before_update_pm_message BEFORE UPDATE OF message ON pms FOR EACH ROW EXECUTE PROCEDURE pm_revision();
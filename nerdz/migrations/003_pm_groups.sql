@@ -0,0 +1,30 @@
+-- Group conversations: the conversation, its participants (with the admin role) and its messages.
+
+CREATE TABLE pm_groups(
+    id bigserial NOT NULL PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+CREATE TABLE pm_group_members(
+    group_id bigint NOT NULL REFERENCES pm_groups(id) ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    admin boolean NOT NULL DEFAULT FALSE,
+    last_read bigint NOT NULL DEFAULT 0,
+    joined_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY,
+    UNIQUE(group_id, user_id)
+);
+
+CREATE INDEX pm_group_members_user_id_idx ON pm_group_members(user_id);
+
+CREATE TABLE pm_group_messages(
+    pmid bigserial NOT NULL PRIMARY KEY,
+    group_id bigint NOT NULL REFERENCES pm_groups(id) ON DELETE CASCADE,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    message text NOT NULL,
+    lang varchar(2) NOT NULL,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+CREATE INDEX pm_group_messages_group_id_idx ON pm_group_messages(group_id, pmid);
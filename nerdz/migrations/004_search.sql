@@ -0,0 +1,8 @@
+-- Full-text search indexes of the messages. The "simple" configuration doesn't stem the words,
+-- since the messages are in many languages.
+
+CREATE INDEX posts_message_fts_idx ON posts USING gin(to_tsvector('simple', message));
+CREATE INDEX groups_posts_message_fts_idx ON groups_posts USING gin(to_tsvector('simple', message));
+CREATE INDEX comments_message_fts_idx ON comments USING gin(to_tsvector('simple', message));
+CREATE INDEX groups_comments_message_fts_idx ON groups_comments USING gin(to_tsvector('simple', message));
+CREATE INDEX pms_message_fts_idx ON pms USING gin(to_tsvector('simple', message));
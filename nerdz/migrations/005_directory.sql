@@ -0,0 +1,6 @@
+-- Trigram indexes for the prefix and fuzzy search of the usernames and of the project names.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX users_username_trgm_idx ON users USING gin(LOWER(username) gin_trgm_ops);
+CREATE INDEX groups_name_trgm_idx ON groups USING gin(LOWER(name) gin_trgm_ops);
@@ -0,0 +1,19 @@
+-- Project membership workflow: the pending invitations and join requests,
+-- and the notifications of the membership changes, that don't reference a post.
+
+CREATE TABLE groups_membership_requests(
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "to" bigint NOT NULL REFERENCES groups(counter) ON DELETE CASCADE,
+    kind varchar(7) NOT NULL CHECK (kind IN ('invite', 'request')),
+    sender bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY,
+    UNIQUE("from", "to")
+);
+
+CREATE INDEX groups_membership_requests_to_idx ON groups_membership_requests("to");
+
+ALTER TABLE groups_notify ALTER COLUMN hpid DROP NOT NULL;
+ALTER TABLE groups_notify ADD COLUMN reason varchar(7) NOT NULL DEFAULT 'post'
+    CHECK (reason IN ('post', 'invite', 'request', 'join', 'accept', 'deny', 'leave', 'kick'));
+ALTER TABLE groups_notify ADD COLUMN "user" bigint REFERENCES users(counter) ON DELETE CASCADE;
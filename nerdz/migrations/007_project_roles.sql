@@ -0,0 +1,4 @@
+-- Roles of the project members. The owner is stored in groups_owners.
+
+ALTER TABLE groups_members ADD COLUMN role varchar(9) NOT NULL DEFAULT 'member'
+    CHECK (role IN ('admin', 'moderator', 'member'));
@@ -0,0 +1,21 @@
+-- Posts pinned to the top of the user and project boards.
+
+CREATE TABLE pins(
+    hpid bigint NOT NULL UNIQUE REFERENCES posts(hpid) ON DELETE CASCADE,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "to" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY
+);
+
+CREATE INDEX pins_to_idx ON pins("to");
+
+CREATE TABLE groups_pins(
+    hpid bigint NOT NULL UNIQUE REFERENCES groups_posts(hpid) ON DELETE CASCADE,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "to" bigint NOT NULL REFERENCES groups(counter) ON DELETE CASCADE,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY
+);
+
+CREATE INDEX groups_pins_to_idx ON groups_pins("to");
@@ -0,0 +1,4 @@
+-- Board settings: when whitelist_only is true, only the whitelisted users
+-- can write posts and comments on the user board.
+
+ALTER TABLE profiles ADD COLUMN whitelist_only boolean NOT NULL DEFAULT false;
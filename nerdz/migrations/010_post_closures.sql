@@ -0,0 +1,16 @@
+-- Users that closed the user and project posts: posts.closed and groups_posts.closed
+-- are the closure state, these relations keep who closed the posts.
+
+CREATE TABLE posts_closures(
+    hpid bigint NOT NULL UNIQUE REFERENCES posts(hpid) ON DELETE CASCADE,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY
+);
+
+CREATE TABLE groups_posts_closures(
+    hpid bigint NOT NULL UNIQUE REFERENCES groups_posts(hpid) ON DELETE CASCADE,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "time" timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    counter bigserial NOT NULL PRIMARY KEY
+);
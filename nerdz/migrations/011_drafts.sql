@@ -0,0 +1,18 @@
+-- Posts not yet published: drafts (publish_at is NULL) and posts scheduled for publish_at.
+-- "to" is a user or a project, depending on type. error is why the last publication failed.
+
+CREATE TABLE drafts(
+    id bigserial NOT NULL PRIMARY KEY,
+    "from" bigint NOT NULL REFERENCES users(counter) ON DELETE CASCADE,
+    "to" bigint NOT NULL,
+    type varchar(7) NOT NULL CHECK (type IN ('user', 'project')),
+    message text NOT NULL,
+    lang varchar(2) NOT NULL DEFAULT '',
+    publish_at timestamp without time zone,
+    error text NOT NULL DEFAULT '',
+    created_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc'),
+    updated_at timestamp without time zone NOT NULL DEFAULT (now() at time zone 'utc')
+);
+
+CREATE INDEX drafts_from_idx ON drafts("from");
+CREATE INDEX drafts_publish_at_idx ON drafts(publish_at) WHERE publish_at IS NOT NULL;
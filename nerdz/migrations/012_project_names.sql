@@ -0,0 +1,4 @@
+-- The project names are unique, case insensitive: the constraint prevents two concurrent
+-- creations of a project with the same name, that the check before the insertion can't prevent.
+
+CREATE UNIQUE INDEX IF NOT EXISTS groups_lower_name_idx ON groups(LOWER(name));
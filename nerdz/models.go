@@ -286,6 +286,8 @@ type ProjectNotify struct {
 	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
 	Hpid    uint64
 	Counter uint64 `igor:"primary_key"`
+	Reason  string `sql:"default:'post'"`
+	User    uint64
 }
 
 // TableName returns the table name associated with the structure
@@ -302,6 +304,12 @@ func (p *ProjectNotify) GetTO(users ...*User) *ProjectNotifyTO {
 	if to, e := NewUser(p.To); e == nil {
 		toInfo = to.Info().GetTO()
 	}
+	var userInfo *InfoTO
+	if p.User != 0 {
+		if user, e := NewUser(p.User); e == nil {
+			userInfo = user.Info().GetTO()
+		}
+	}
 	return &ProjectNotifyTO{
 		original:  p,
 		FromInfo:  fromInfo,
@@ -310,6 +318,8 @@ func (p *ProjectNotify) GetTO(users ...*User) *ProjectNotifyTO {
 		Timestamp: p.Time.Unix(),
 		Hpid:      p.Hpid,
 		Counter:   p.Counter,
+		Reason:    p.Reason,
+		UserInfo:  userInfo,
 	}
 }
 
@@ -475,6 +485,7 @@ func (u *User) GetTO(users ...*User) *UserTO {
 			Push:           u.Profile.Push,
 			Pushregtime:    u.Profile.Pushregtime,
 			Closed:         u.Profile.Closed,
+			WhitelistOnly:  u.Profile.WhitelistOnly,
 		},
 	}
 }
@@ -499,6 +510,7 @@ type Profile struct {
 	Push           bool
 	Pushregtime    time.Time `sql:"default:(now() at time zone 'utc')"`
 	Closed         bool
+	WhitelistOnly  bool
 }
 
 // TableName returns the table name associated with the structure
@@ -594,6 +606,14 @@ func (p *UserPost) GetTO(users ...*User) *PostTO {
 	postTO.CanDelete = user.CanDelete(p)
 	postTO.CanEdit = user.CanEdit(p)
 	postTO.CanLurk = user.CanLurk(p)
+	postTO.CanPin = user.CanPin(p)
+	postTO.CanClose = user.CanClose(p)
+	postTO.Pinned = p.IsPinned()
+	if p.Closed {
+		if closer, e := NewUser(p.NumericCloser()); e == nil {
+			postTO.ClosedBy = closer.Info().GetTO()
+		}
+	}
 	return postTO
 }
 
@@ -665,6 +685,35 @@ func (t *UserPostVote) GetTO(users ...*User) *UserPostVoteTO {
 	}
 }
 
+// UserPostPin is the model for the relation pins.
+// It's a post pinned by the user (From) to the top of the user board (To)
+type UserPostPin struct {
+	Hpid    uint64
+	From    uint64
+	To      uint64
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter uint64    `igor:"primary_key"`
+}
+
+// TableName returns the table name associated with the structure
+func (UserPostPin) TableName() string {
+	return "pins"
+}
+
+// UserPostClosure is the model for the relation posts_closures.
+// It's the user (From) that closed the post
+type UserPostClosure struct {
+	Hpid    uint64
+	From    uint64
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter uint64    `igor:"primary_key"`
+}
+
+// TableName returns the table name associated with the structure
+func (UserPostClosure) TableName() string {
+	return "posts_closures"
+}
+
 // UserPostLurk is the model for the relation lurkers
 type UserPostLurk struct {
 	Hpid    uint64
@@ -827,17 +876,18 @@ func (p *Pm) GetTO(users ...*User) *PmTO {
 		toInfo = to.Info().GetTO()
 	}
 	return &PmTO{
-		original:  p,
-		Pmid:      p.Pmid,
-		FromInfo:  fromInfo,
-		ToInfo:    toInfo,
-		Message:   p.Message,
-		Lang:      p.Lang,
-		ToRead:    p.ToRead,
-		Time:      p.Time,
-		Timestamp: p.Time.Unix(),
-		CanDelete: user.CanDelete(p),
-		CanEdit:   user.CanEdit(p),
+		original:       p,
+		Pmid:           p.Pmid,
+		FromInfo:       fromInfo,
+		ToInfo:         toInfo,
+		Message:        p.Message,
+		Lang:           p.Lang,
+		ToRead:         p.ToRead,
+		Time:           p.Time,
+		Timestamp:      p.Time.Unix(),
+		CanDelete:      user.CanDelete(p),
+		CanEdit:        user.CanEdit(p),
+		RevisionsCount: p.RevisionsNumber(),
 	}
 }
 
@@ -846,6 +896,127 @@ func (Pm) TableName() string {
 	return "pms"
 }
 
+// PmRevision is the model for the relation pms_revisions
+type PmRevision struct {
+	Pmid    uint64
+	Message string
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	RevNo   uint16
+	Counter uint64 `igor:"primary_key"`
+}
+
+// GetTO returns its Transfer Object
+func (r *PmRevision) GetTO(users ...*User) *PmRevisionTO {
+	return &PmRevisionTO{
+		original:  r,
+		Pmid:      r.Pmid,
+		Message:   r.Message,
+		Time:      r.Time,
+		Timestamp: r.Time.Unix(),
+		RevNo:     r.RevNo,
+		Counter:   r.Counter,
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (PmRevision) TableName() string {
+	return "pms_revisions"
+}
+
+// PmGroup is the model for the relation pm_groups.
+// It's a private conversation among many users
+type PmGroup struct {
+	ID        uint64 `igor:"primary_key"`
+	Name      string
+	CreatedAt time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// GetTO returns its Transfer Object
+func (g *PmGroup) GetTO(users ...*User) *PmGroupTO {
+	var members []*PmGroupMemberTO
+	for _, m := range g.Memberships() {
+		member := m
+		members = append(members, member.GetTO())
+	}
+	return &PmGroupTO{
+		original:  g,
+		ID:        g.ID,
+		Name:      g.Name,
+		Members:   members,
+		CreatedAt: g.CreatedAt,
+		Timestamp: g.CreatedAt.Unix(),
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (PmGroup) TableName() string {
+	return "pm_groups"
+}
+
+// PmGroupMember is the model for the relation pm_group_members.
+// LastRead is the ID of the last message of the group read by the user
+type PmGroupMember struct {
+	GroupID  uint64
+	UserID   uint64
+	Admin    bool
+	LastRead uint64
+	JoinedAt time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter  uint64    `igor:"primary_key"`
+}
+
+// GetTO returns its Transfer Object
+func (m *PmGroupMember) GetTO(users ...*User) *PmGroupMemberTO {
+	var userInfo *InfoTO
+	if user, e := NewUser(m.UserID); e == nil {
+		userInfo = user.Info().GetTO()
+	}
+	return &PmGroupMemberTO{
+		original:  m,
+		UserInfo:  userInfo,
+		Admin:     m.Admin,
+		JoinedAt:  m.JoinedAt,
+		Timestamp: m.JoinedAt.Unix(),
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (PmGroupMember) TableName() string {
+	return "pm_group_members"
+}
+
+// PmGroupMessage is the model for the relation pm_group_messages
+type PmGroupMessage struct {
+	Pmid    uint64 `igor:"primary_key"`
+	GroupID uint64
+	From    uint64
+	Message string
+	Lang    string
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// GetTO returns its Transfer Object
+func (m *PmGroupMessage) GetTO(users ...*User) *PmGroupMessageTO {
+	var fromInfo *InfoTO
+	if from, e := NewUser(m.From); e == nil {
+		fromInfo = from.Info().GetTO()
+	}
+	return &PmGroupMessageTO{
+		original:  m,
+		Pmid:      m.Pmid,
+		GroupID:   m.GroupID,
+		FromInfo:  fromInfo,
+		Message:   m.Message,
+		Lang:      m.Lang,
+		Time:      m.Time,
+		Timestamp: m.Time.Unix(),
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (PmGroupMessage) TableName() string {
+	return "pm_group_messages"
+}
+
 // Project is the model for the relation groups
 type Project struct {
 	Counter      uint64 `igor:"primary_key"`
@@ -889,6 +1060,7 @@ type ProjectMember struct {
 	Time     time.Time `sql:"default:(now() at time zone 'utc')"`
 	ToNotify bool
 	Counter  uint64 `igor:"primary_key"`
+	Role     string `sql:"default:'member'"`
 }
 
 // GetTO returns its Transfer Object
@@ -908,6 +1080,7 @@ func (m *ProjectMember) GetTO(users ...*User) *ProjectMemberTO {
 		Timestamp: m.Time.Unix(),
 		ToNotify:  m.ToNotify,
 		Counter:   m.Counter,
+		Role:      m.Role,
 	}
 }
 
@@ -916,6 +1089,46 @@ func (ProjectMember) TableName() string {
 	return "groups_members"
 }
 
+// ProjectMembershipRequest is the model for the relation groups_membership_requests.
+// It's a pending invitation of the user (From) to the project (To), or join request of the user
+type ProjectMembershipRequest struct {
+	From    uint64
+	To      uint64
+	Kind    string
+	Sender  uint64
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter uint64    `igor:"primary_key"`
+}
+
+// GetTO returns its Transfer Object
+func (r *ProjectMembershipRequest) GetTO(users ...*User) *ProjectMembershipRequestTO {
+	var fromInfo, toInfo, senderInfo *InfoTO
+	if from, e := NewUser(r.From); e == nil {
+		fromInfo = from.Info().GetTO()
+	}
+	if to, e := NewProject(r.To); e == nil {
+		toInfo = to.Info().GetTO()
+	}
+	if sender, e := NewUser(r.Sender); e == nil {
+		senderInfo = sender.Info().GetTO()
+	}
+	return &ProjectMembershipRequestTO{
+		original:   r,
+		FromInfo:   fromInfo,
+		ToInfo:     toInfo,
+		Kind:       r.Kind,
+		SenderInfo: senderInfo,
+		Time:       r.Time,
+		Timestamp:  r.Time.Unix(),
+		Counter:    r.Counter,
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (ProjectMembershipRequest) TableName() string {
+	return "groups_membership_requests"
+}
+
 // ProjectOwner is the model for the relation groups_owners
 type ProjectOwner struct {
 	From     uint64
@@ -989,6 +1202,14 @@ func (p *ProjectPost) GetTO(users ...*User) *PostTO {
 	postTO.CanDelete = user.CanDelete(p)
 	postTO.CanEdit = user.CanEdit(p)
 	postTO.CanLurk = user.CanLurk(p)
+	postTO.CanPin = user.CanPin(p)
+	postTO.CanClose = user.CanClose(p)
+	postTO.Pinned = p.IsPinned()
+	if p.Closed {
+		if closer, e := NewUser(p.NumericCloser()); e == nil {
+			postTO.ClosedBy = closer.Info().GetTO()
+		}
+	}
 	return postTO
 }
 
@@ -1055,6 +1276,35 @@ func (t *ProjectPostVote) GetTO(users ...*User) *ProjectPostVoteTO {
 	}
 }
 
+// ProjectPostPin is the model for the relation groups_pins.
+// It's a post pinned by the user (From) to the top of the project board (To)
+type ProjectPostPin struct {
+	Hpid    uint64
+	From    uint64
+	To      uint64
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter uint64    `igor:"primary_key"`
+}
+
+// TableName returns the table name associated with the structure
+func (ProjectPostPin) TableName() string {
+	return "groups_pins"
+}
+
+// ProjectPostClosure is the model for the relation groups_posts_closures.
+// It's the user (From) that closed the post
+type ProjectPostClosure struct {
+	Hpid    uint64
+	From    uint64
+	Time    time.Time `sql:"default:(now() at time zone 'utc')"`
+	Counter uint64    `igor:"primary_key"`
+}
+
+// TableName returns the table name associated with the structure
+func (ProjectPostClosure) TableName() string {
+	return "groups_posts_closures"
+}
+
 // ProjectPostLurk is the model for the relation groups_lurkers
 type ProjectPostLurk struct {
 	Hpid    uint64
@@ -1399,7 +1649,7 @@ func (m *Mention) GetTO(users ...*User) *MentionTO {
 	if to, e := NewUser(m.To); e == nil {
 		toInfo = to.Info().GetTO()
 	}
-	return &MentionTO{
+	to := &MentionTO{
 		original:  m,
 		ID:        m.ID,
 		UHpid:     m.UHpid,
@@ -1410,6 +1660,22 @@ func (m *Mention) GetTO(users ...*User) *MentionTO {
 		Timestamp: m.Time.Unix(),
 		ToNotify:  m.ToNotify,
 	}
+	// the referenced post is expanded when the viewer is known and can see its board
+	if len(users) == 1 {
+		if board, e := m.Board(); e != nil || !users[0].CanSee(board) {
+			return to
+		}
+		if m.UHpid != 0 {
+			if post, e := NewUserPost(m.UHpid); e == nil {
+				to.Post = post.GetTO(users[0])
+			}
+		} else if m.GHpid != 0 {
+			if post, e := NewProjectPost(m.GHpid); e == nil {
+				to.Post = post.GetTO(users[0])
+			}
+		}
+	}
+	return to
 }
 
 // TableName returns the table name associated with the structure
@@ -1528,3 +1794,140 @@ type OAuth2RefreshToken struct {
 func (OAuth2RefreshToken) TableName() string {
 	return "oauth2_refresh"
 }
+
+// Webhook is the model for the relation oauth2_webhooks.
+// It's the URL registered by an OAuth2 client to receive the events of type Event
+type Webhook struct {
+	ID        uint64 `igor:"primary_key"`
+	ClientID  uint64
+	URL       string
+	Event     string
+	CreatedAt time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// GetTO returns its Transfer Object
+func (w *Webhook) GetTO(users ...*User) *WebhookTO {
+	return &WebhookTO{
+		original:  w,
+		ID:        w.ID,
+		ClientID:  w.ClientID,
+		URL:       w.URL,
+		Event:     w.Event,
+		CreatedAt: w.CreatedAt,
+		Timestamp: w.CreatedAt.Unix(),
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (Webhook) TableName() string {
+	return "oauth2_webhooks"
+}
+
+// WebhookDelivery is the model for the relation oauth2_webhook_deliveries.
+// It's the delivery of an event, about the user UserID, to a webhook
+type WebhookDelivery struct {
+	ID            uint64 `igor:"primary_key"`
+	WebhookID     uint64
+	UserID        uint64
+	Event         string
+	Payload       string
+	Status        string `sql:"default:pending"`
+	Attempts      uint8
+	StatusCode    int
+	LastError     string
+	CreatedAt     time.Time `sql:"default:(now() at time zone 'utc')"`
+	NextAttemptAt time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// GetTO returns its Transfer Object
+func (d *WebhookDelivery) GetTO(users ...*User) *WebhookDeliveryTO {
+	return &WebhookDeliveryTO{
+		original:      d,
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		StatusCode:    d.StatusCode,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+		NextAttemptAt: d.NextAttemptAt,
+		Timestamp:     d.CreatedAt.Unix(),
+	}
+}
+
+// TableName returns the table name associated with the structure
+func (WebhookDelivery) TableName() string {
+	return "oauth2_webhook_deliveries"
+}
+
+// WebhookDeadLetter is the model for the relation oauth2_webhook_dead_letters.
+// It's a delivery that failed too many times
+type WebhookDeadLetter struct {
+	ID         uint64 `igor:"primary_key"`
+	DeliveryID uint64
+	WebhookID  uint64
+	Event      string
+	Payload    string
+	Attempts   uint8
+	LastError  string
+	CreatedAt  time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// TableName returns the table name associated with the structure
+func (WebhookDeadLetter) TableName() string {
+	return "oauth2_webhook_dead_letters"
+}
+
+// Draft is the model for the relation drafts.
+// It's a post of the user (From) not yet published on the board (To) of type Type:
+// a draft when PublishAt is not valid, a post scheduled for PublishAt otherwise
+type Draft struct {
+	ID        uint64 `igor:"primary_key"`
+	From      uint64
+	To        uint64
+	Type      boardType
+	Message   string
+	Lang      string
+	PublishAt sql.NullTime
+	Error     string
+	CreatedAt time.Time `sql:"default:(now() at time zone 'utc')"`
+	UpdatedAt time.Time `sql:"default:(now() at time zone 'utc')"`
+}
+
+// GetTO returns its Transfer Object
+func (d *Draft) GetTO(users ...*User) *DraftTO {
+	to := DraftTO{
+		original:  d,
+		ID:        d.ID,
+		Type:      d.Type,
+		Message:   d.Message,
+		Lang:      d.Lang,
+		Scheduled: d.PublishAt.Valid,
+		Error:     d.Error,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		Timestamp: d.UpdatedAt.Unix(),
+	}
+	if d.PublishAt.Valid {
+		to.PublishAt = &d.PublishAt.Time
+		to.PublishTimestamp = d.PublishAt.Time.Unix()
+	}
+	if from, e := NewUser(d.From); e == nil {
+		to.FromInfo = from.Info().GetTO()
+	}
+	if d.Type == UserBoardID {
+		if board, e := NewUser(d.To); e == nil {
+			to.ToInfo = board.Info().GetTO()
+		}
+	} else if board, e := NewProject(d.To); e == nil {
+		to.ToInfo = board.Info().GetTO()
+	}
+	return &to
+}
+
+// TableName returns the table name associated with the structure
+func (Draft) TableName() string {
+	return "drafts"
+}
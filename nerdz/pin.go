@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+
+	"github.com/labstack/gommon/log"
+)
+
+// MaxPinnedPosts is the maximum number of posts pinned to the top of a board
+const MaxPinnedPosts = 3
+
+// IsPinned returns true if the post is pinned to the top of the board
+func (post *UserPost) IsPinned() bool {
+	var count uint8
+	_ = Db().Model(UserPostPin{}).Where(&UserPostPin{Hpid: post.ID()}).Count(&count)
+	return count > 0
+}
+
+// IsPinned returns true if the post is pinned to the top of the board
+func (post *ProjectPost) IsPinned() bool {
+	var count uint8
+	_ = Db().Model(ProjectPostPin{}).Where(&ProjectPostPin{Hpid: post.ID()}).Count(&count)
+	return count > 0
+}
+
+// PinnedPosts returns the posts pinned to the top of the user board, from the newest
+func (user *User) PinnedPosts() *[]ExistingPost {
+	var posts []UserPost
+	if err := Db().Model(UserPost{}).Where(`hpid IN (SELECT hpid FROM `+UserPostPin{}.TableName()+` WHERE "to" = ?)`, user.ID()).
+		Order("hpid DESC").Scan(&posts); err != nil && err != sql.ErrNoRows {
+		log.Errorf("(User::PinnedPosts) Error in query.Scan: %s", err)
+	}
+
+	var retPosts []ExistingPost
+	for _, p := range posts {
+		userPost := p
+		retPosts = append(retPosts, ExistingPost(&userPost))
+	}
+	return &retPosts
+}
+
+// PinnedPosts returns the posts pinned to the top of the project board, from the newest
+func (prj *Project) PinnedPosts() *[]ExistingPost {
+	var posts []ProjectPost
+	if err := Db().Model(ProjectPost{}).Where(`hpid IN (SELECT hpid FROM `+ProjectPostPin{}.TableName()+` WHERE "to" = ?)`, prj.ID()).
+		Order("hpid DESC").Scan(&posts); err != nil && err != sql.ErrNoRows {
+		log.Errorf("(Project::PinnedPosts) Error in query.Scan: %s", err)
+	}
+
+	var retPosts []ExistingPost
+	for _, p := range posts {
+		projectPost := p
+		retPosts = append(retPosts, ExistingPost(&projectPost))
+	}
+	return &retPosts
+}
+
+// CanPin returns true if the user can pin and unpin the post: the owner of the user board,
+// or the roles of the project with the permission to pin
+func (user *User) CanPin(post ExistingPost) bool {
+	switch post := post.(type) {
+	case *UserPost:
+		return post.ID() > 0 && post.To == user.ID()
+	case *ProjectPost:
+		return post.ID() > 0 && projectHasPermission(post.To, user, ProjectPermissionPin)
+	}
+	return false
+}
+
+// Pin pins the post to the top of its board. A board has at most MaxPinnedPosts pinned posts
+func (user *User) Pin(post ExistingPost) error {
+	if post == nil {
+		return errors.New("unable to pin undefined post")
+	}
+	if !user.CanPin(post) {
+		return errors.New("you can't pin the posts of this board")
+	}
+	if post.IsPinned() {
+		return errors.New("the post is already pinned")
+	}
+
+	var count uint8
+	switch post := post.(type) {
+	case *UserPost:
+		_ = Db().Model(UserPostPin{}).Where(&UserPostPin{To: post.To}).Count(&count)
+		if count >= MaxPinnedPosts {
+			return errors.New("the board has already the maximum number of pinned posts")
+		}
+		return Db().Create(&UserPostPin{Hpid: post.ID(), From: user.ID(), To: post.To})
+
+	case *ProjectPost:
+		_ = Db().Model(ProjectPostPin{}).Where(&ProjectPostPin{To: post.To}).Count(&count)
+		if count >= MaxPinnedPosts {
+			return errors.New("the board has already the maximum number of pinned posts")
+		}
+		return Db().Create(&ProjectPostPin{Hpid: post.ID(), From: user.ID(), To: post.To})
+	}
+
+	return errors.New("invalid post type " + reflect.TypeOf(post).String())
+}
+
+// Unpin removes the post from the top of its board
+func (user *User) Unpin(post ExistingPost) error {
+	if post == nil {
+		return errors.New("unable to unpin undefined post")
+	}
+	if !user.CanPin(post) {
+		return errors.New("you can't unpin the posts of this board")
+	}
+	if !post.IsPinned() {
+		return errors.New("the post is not pinned")
+	}
+
+	switch post := post.(type) {
+	case *UserPost:
+		return Db().Where(&UserPostPin{Hpid: post.ID()}).Delete(UserPostPin{})
+	case *ProjectPost:
+		return Db().Where(&ProjectPostPin{Hpid: post.ID()}).Delete(ProjectPostPin{})
+	}
+
+	return errors.New("invalid post type " + reflect.TypeOf(post).String())
+}
@@ -53,33 +53,66 @@ func pmsQueryBuilder(query *igor.Database, options PmsOptions) *igor.Database {
 	return query
 }
 
-// Conversation represents the details about a single private conversation between two users
+// Conversation represents the details about a single private conversation between two users,
+// or about a group conversation. In a group conversation To is 0, Group is the group ID
+// and From is the sender of the last message (0 if there are no messages)
 type Conversation struct {
 	From        uint64
 	To          uint64
 	LastMessage string
 	Time        time.Time
 	ToRead      bool
+	Group       uint64
 }
 
 // GetTO returns is Transfer Object
 func (c *Conversation) GetTO(users ...*User) *ConversationTO {
 	var fromInfo, toInfo *InfoTO
-	if from, e := NewUser(c.From); e == nil {
-		fromInfo = from.Info().GetTO()
+	var groupTO *PmGroupTO
+	if c.From != 0 {
+		if from, e := NewUser(c.From); e == nil {
+			fromInfo = from.Info().GetTO()
+		}
+	}
+	if c.To != 0 {
+		if to, e := NewUser(c.To); e == nil {
+			toInfo = to.Info().GetTO()
+		}
 	}
-	if to, e := NewUser(c.To); e == nil {
-		toInfo = to.Info().GetTO()
+	if c.Group != 0 {
+		if group, e := NewPmGroup(c.Group); e == nil {
+			groupTO = group.GetTO()
+		}
 	}
 	return &ConversationTO{
 		FromInfo:    fromInfo,
 		ToInfo:      toInfo,
+		Group:       groupTO,
 		LastMessage: c.LastMessage,
 		Time:        c.Time,
 		ToRead:      c.ToRead,
 	}
 }
 
+// UnreadConversation represents the number of unread pms sent by a user
+type UnreadConversation struct {
+	From  uint64
+	Count uint64
+}
+
+// GetTO returns its Transfer Object
+func (u *UnreadConversation) GetTO(users ...*User) *UnreadConversationTO {
+	var fromInfo *InfoTO
+	if from, e := NewUser(u.From); e == nil {
+		fromInfo = from.Info().GetTO()
+	}
+	return &UnreadConversationTO{
+		original: u,
+		FromInfo: fromInfo,
+		Count:    u.Count,
+	}
+}
+
 // NewPm initializes a Pm struct
 func NewPm(pmid uint64) (*Pm, error) {
 	return NewPmWhere(&Pm{Pmid: pmid})
@@ -172,7 +205,7 @@ func (pm *Pm) Text() string {
 
 // IsEditable returns true if the pm is editable
 func (pm *Pm) IsEditable() bool {
-	return false
+	return true
 }
 
 // NumericOwners returns a slice of ids of the owner of the pms (the ones that can perform actions)
@@ -187,12 +220,20 @@ func (pm *Pm) Owners() (ret []*User) {
 
 // Revisions returns all the revisions of the message
 func (pm *Pm) Revisions() (modifications []string) {
+	_ = Db().Model(PmRevision{}).Where(&PmRevision{Pmid: pm.ID()}).Order("rev_no").Pluck("message", &modifications)
+	return
+}
+
+// History returns all the revisions of the message, from the first one
+func (pm *Pm) History() (revisions []PmRevision) {
+	_ = Db().Model(PmRevision{}).Where(&PmRevision{Pmid: pm.ID()}).Order("rev_no").Scan(&revisions)
 	return
 }
 
 // RevisionsNumber returns the number of the revisions
-func (pm *Pm) RevisionsNumber() uint8 {
-	return 0
+func (pm *Pm) RevisionsNumber() (count uint8) {
+	_ = Db().Model(PmRevision{}).Where(&PmRevision{Pmid: pm.ID()}).Count(&count)
+	return
 }
 
 // Votes returns the pm's votes value
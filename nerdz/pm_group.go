@@ -0,0 +1,292 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+const (
+	// maxPmGroupNameLength is the maximum length of the name of a group conversation
+	maxPmGroupNameLength = 100
+	// MaxPmGroupMembers is the maximum number of participants of a group conversation
+	MaxPmGroupMembers = 50
+)
+
+// NewPmGroup returns the group conversation identified by id
+func NewPmGroup(id uint64) (*PmGroup, error) {
+	if id == 0 {
+		return nil, errors.New("requested group conversation does not exist")
+	}
+	group := new(PmGroup)
+	if err := Db().First(group, id); err != nil {
+		return nil, err
+	}
+	if group.ID == 0 {
+		return nil, errors.New("requested group conversation does not exist")
+	}
+	return group, nil
+}
+
+// Memberships returns the memberships of the participants, in order of joining
+func (g *PmGroup) Memberships() (members []PmGroupMember) {
+	_ = Db().Model(PmGroupMember{}).Where(&PmGroupMember{GroupID: g.ID}).Order("joined_at, counter").Scan(&members)
+	return
+}
+
+// NumericMembers returns the IDs of the participants
+func (g *PmGroup) NumericMembers() (members []uint64) {
+	_ = Db().Model(PmGroupMember{}).Where(&PmGroupMember{GroupID: g.ID}).Order("joined_at, counter").Pluck("user_id", &members)
+	return
+}
+
+// Members returns the participants
+func (g *PmGroup) Members() []*User {
+	return Users(g.NumericMembers())
+}
+
+// NumericAdmins returns the IDs of the participants with the admin role
+func (g *PmGroup) NumericAdmins() (admins []uint64) {
+	_ = Db().Model(PmGroupMember{}).Where(&PmGroupMember{GroupID: g.ID}).Where("admin").Pluck("user_id", &admins)
+	return
+}
+
+// HasMember returns true if the user participates in the group conversation
+func (g *PmGroup) HasMember(user *User) bool {
+	return utils.InSlice(user.ID(), g.NumericMembers())
+}
+
+// HasAdmin returns true if the user is an admin of the group conversation
+func (g *PmGroup) HasAdmin(user *User) bool {
+	return utils.InSlice(user.ID(), g.NumericAdmins())
+}
+
+// Messages returns the messages of the group conversation, selected by the options
+func (g *PmGroup) Messages(options PmsOptions) (*[]PmGroupMessage, error) {
+	var messages []PmGroupMessage
+	query := pmsQueryBuilder(Db().Model(PmGroupMessage{}).Where(&PmGroupMessage{GroupID: g.ID}), options)
+	// a group conversation without messages has no rows
+	if err := query.Scan(&messages); err != nil && err != sql.ErrNoRows {
+		return &messages, err
+	}
+	return &messages, nil
+}
+
+// PmGroups returns the group conversations of the user, in order of joining
+func (user *User) PmGroups() (groups []*PmGroup) {
+	var ids []uint64
+	_ = Db().Model(PmGroupMember{}).Where(&PmGroupMember{UserID: user.ID()}).Order("joined_at, counter").Pluck("group_id", &ids)
+	for _, id := range ids {
+		if group, err := NewPmGroup(id); err == nil {
+			groups = append(groups, group)
+		}
+	}
+	return
+}
+
+// checkPmGroupBlacklist returns an error if the user blacklisted,
+// or has been blacklisted by, one of the other users
+func (user *User) checkPmGroupBlacklist(others []uint64) error {
+	blacklist := append(user.NumericBlacklist(), user.NumericBlacklisting()...)
+	for _, other := range others {
+		if other != user.ID() && utils.InSlice(other, blacklist) {
+			return fmt.Errorf("%s and one of the participants blacklisted each other", user.Username)
+		}
+	}
+	return nil
+}
+
+// CreatePmGroup creates a group conversation, with the user as admin and the others as participants.
+// The participants must not have blacklisted each other
+func (user *User) CreatePmGroup(name string, others []uint64) (*PmGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxPmGroupNameLength {
+		return nil, errors.New("the name must be not empty and at most 100 characters long")
+	}
+
+	members := []*User{user}
+	ids := []uint64{user.ID()}
+	for _, id := range others {
+		if utils.InSlice(id, ids) {
+			continue
+		}
+		if id == 0 {
+			return nil, errors.New("invalid participant ID 0")
+		}
+		other, err := NewUser(id)
+		if err != nil {
+			return nil, fmt.Errorf("user %d does not exist", id)
+		}
+		members = append(members, other)
+		ids = append(ids, id)
+	}
+	if len(members) < 2 {
+		return nil, errors.New("a group conversation requires at least another participant")
+	}
+	if len(members) > MaxPmGroupMembers {
+		return nil, fmt.Errorf("a group conversation can have at most %d participants", MaxPmGroupMembers)
+	}
+	for _, member := range members {
+		if err := member.checkPmGroupBlacklist(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := Db().Begin()
+	group := PmGroup{Name: name}
+	if err := tx.Create(&group); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	for _, member := range members {
+		if err := tx.Create(&PmGroupMember{GroupID: group.ID, UserID: member.ID(), Admin: member.ID() == user.ID()}); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// AddPmGroupMember adds the other user to the group conversation. Only an admin can add participants,
+// and the other user must not have blacklisted, or been blacklisted by, a participant
+func (user *User) AddPmGroupMember(group *PmGroup, other *User) error {
+	if !group.HasAdmin(user) {
+		return errors.New("only an admin can add participants to the group conversation")
+	}
+	members := group.NumericMembers()
+	if utils.InSlice(other.ID(), members) {
+		return fmt.Errorf("%s already participates in the group conversation", other.Username)
+	}
+	if len(members) >= MaxPmGroupMembers {
+		return fmt.Errorf("a group conversation can have at most %d participants", MaxPmGroupMembers)
+	}
+	if err := other.checkPmGroupBlacklist(members); err != nil {
+		return err
+	}
+	return Db().Create(&PmGroupMember{GroupID: group.ID, UserID: other.ID()})
+}
+
+// RemovePmGroupMember removes the other user from the group conversation.
+// Only an admin can remove other participants, everybody can leave
+func (user *User) RemovePmGroupMember(group *PmGroup, other *User) error {
+	if other.ID() == user.ID() {
+		return user.LeavePmGroup(group)
+	}
+	if !group.HasAdmin(user) {
+		return errors.New("only an admin can remove participants from the group conversation")
+	}
+	return other.LeavePmGroup(group)
+}
+
+// LeavePmGroup removes the user from the group conversation.
+// When the last admin leaves, the oldest participant becomes admin.
+// When the last participant leaves, the group conversation is deleted
+func (user *User) LeavePmGroup(group *PmGroup) error {
+	if !group.HasMember(user) {
+		return errors.New("you don't participate in the group conversation")
+	}
+	if err := Db().Where(&PmGroupMember{GroupID: group.ID, UserID: user.ID()}).Delete(PmGroupMember{}); err != nil {
+		return err
+	}
+
+	members := group.NumericMembers()
+	if len(members) == 0 {
+		return Db().Delete(group)
+	}
+	if len(group.NumericAdmins()) == 0 {
+		return Db().Exec(`UPDATE pm_group_members SET admin = TRUE WHERE group_id = ? AND user_id = ?`, group.ID, members[0])
+	}
+	return nil
+}
+
+// SetPmGroupAdmin grants, or revokes, the admin role of the other participant.
+// Only an admin can change the roles, and a group conversation always has an admin
+func (user *User) SetPmGroupAdmin(group *PmGroup, other *User, admin bool) error {
+	if !group.HasAdmin(user) {
+		return errors.New("only an admin can change the roles of the participants")
+	}
+	if !group.HasMember(other) {
+		return fmt.Errorf("%s doesn't participate in the group conversation", other.Username)
+	}
+	if !admin && len(group.NumericAdmins()) == 1 && group.HasAdmin(other) {
+		return errors.New("a group conversation requires at least an admin")
+	}
+	return Db().Exec(`UPDATE pm_group_members SET admin = ? WHERE group_id = ? AND user_id = ?`, admin, group.ID, other.ID())
+}
+
+// ReadPmGroup marks as read the messages of the group conversation
+func (user *User) ReadPmGroup(group *PmGroup) error {
+	return Db().Exec(`UPDATE pm_group_members SET last_read = COALESCE((SELECT MAX(pmid) FROM pm_group_messages WHERE group_id = ?), 0)
+	WHERE group_id = ? AND user_id = ?`, group.ID, group.ID, user.ID())
+}
+
+// Implementing newMessage interface
+
+// SetSender sets the source of the message (the user ID)
+func (m *PmGroupMessage) SetSender(id uint64) {
+	m.From = id
+}
+
+// SetReference sets the destination of the message: group ID
+func (m *PmGroupMessage) SetReference(id uint64) {
+	m.GroupID = id
+}
+
+// SetText set the text of the message
+func (m *PmGroupMessage) SetText(message string) {
+	m.Message = message
+}
+
+// SetLanguage set the language of the message
+func (m *PmGroupMessage) SetLanguage(language string) error {
+	if language == "" {
+		if sender, err := NewUser(m.From); err == nil {
+			language = sender.Language()
+		}
+	}
+	if utils.InSlice(language, Configuration.Languages) {
+		m.Lang = language
+		return nil
+	}
+	return fmt.Errorf("Language '%s' is not a valid or supported language", language)
+}
+
+// ClearDefaults set to the go's default values the fields with default sql values
+func (m *PmGroupMessage) ClearDefaults() {
+	m.Time = time.Time{}
+}
+
+// Text returns the message text
+func (m *PmGroupMessage) Text() string {
+	return m.Message
+}
+
+// Language returns the message language
+func (m *PmGroupMessage) Language() string {
+	return m.Lang
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/galeone/igor"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// NumericCloser returns the ID of the user that closed the post,
+// 0 if the post is open or if it has been closed before the closures were kept
+func (post *UserPost) NumericCloser() (closer uint64) {
+	_ = Db().Model(UserPostClosure{}).Select(`"from"`).Where(&UserPostClosure{Hpid: post.ID()}).Scan(&closer)
+	return
+}
+
+// NumericCloser returns the ID of the user that closed the post,
+// 0 if the post is open or if it has been closed before the closures were kept
+func (post *ProjectPost) NumericCloser() (closer uint64) {
+	_ = Db().Model(ProjectPostClosure{}).Select(`"from"`).Where(&ProjectPostClosure{Hpid: post.ID()}).Scan(&closer)
+	return
+}
+
+// CanClose returns true if the user can close and reopen the post: the owners of the post
+func (user *User) CanClose(post ExistingPost) bool {
+	return post.ID() > 0 && utils.InSlice(user.ID(), post.NumericOwners())
+}
+
+// Close closes the post: only the users allowed to comment the closed posts can comment it
+func (user *User) Close(post ExistingPost) error {
+	if post == nil {
+		return errors.New("unable to close undefined post")
+	}
+	if !user.CanClose(post) {
+		return errors.New("you can't close this post")
+	}
+	if post.IsClosed() {
+		return errors.New("the post is already closed")
+	}
+
+	tx := Db().Begin()
+	var err error
+	switch post := post.(type) {
+	case *UserPost:
+		err = closePost(tx, post.TableName(), UserPostClosure{}.TableName(), post.ID(), user.ID())
+	case *ProjectPost:
+		err = closePost(tx, post.TableName(), ProjectPostClosure{}.TableName(), post.ID(), user.ID())
+	default:
+		err = errors.New("invalid post type " + reflect.TypeOf(post).String())
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	setClosed(post, true)
+	return nil
+}
+
+// closePost closes the post with the specified hpid in the posts table and records
+// in the closures table that the user closed it. The closure left by a previous
+// closure (e.g. one not removed by a concurrent reopening) is replaced
+func closePost(tx *igor.Database, posts, closures string, hpid, user uint64) error {
+	if err := tx.Exec(`UPDATE `+posts+` SET closed = TRUE WHERE hpid = ?`, hpid); err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO `+closures+`(hpid, "from") VALUES(?, ?)
+	ON CONFLICT (hpid) DO UPDATE SET "from" = EXCLUDED."from", "time" = EXCLUDED."time"`, hpid, user)
+}
+
+// setClosed sets the closure state of the post, once stored
+func setClosed(post ExistingPost, closed bool) {
+	switch post := post.(type) {
+	case *UserPost:
+		post.Closed = closed
+	case *ProjectPost:
+		post.Closed = closed
+	}
+}
+
+// Reopen reopens the closed post
+func (user *User) Reopen(post ExistingPost) error {
+	if post == nil {
+		return errors.New("unable to reopen undefined post")
+	}
+	if !user.CanClose(post) {
+		return errors.New("you can't reopen this post")
+	}
+	if !post.IsClosed() {
+		return errors.New("the post is not closed")
+	}
+
+	tx := Db().Begin()
+	var err error
+	switch post := post.(type) {
+	case *UserPost:
+		if err = tx.Exec(`UPDATE `+post.TableName()+` SET closed = FALSE WHERE hpid = ?`, post.ID()); err == nil {
+			// the posts closed before the closures were kept have no closure
+			err = tx.Exec(`DELETE FROM `+UserPostClosure{}.TableName()+` WHERE hpid = ?`, post.ID())
+		}
+
+	case *ProjectPost:
+		if err = tx.Exec(`UPDATE `+post.TableName()+` SET closed = FALSE WHERE hpid = ?`, post.ID()); err == nil {
+			// the posts closed before the closures were kept have no closure
+			err = tx.Exec(`DELETE FROM `+ProjectPostClosure{}.TableName()+` WHERE hpid = ?`, post.ID())
+		}
+
+	default:
+		err = errors.New("invalid post type " + reflect.TypeOf(post).String())
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	setClosed(post, false)
+	return nil
+}
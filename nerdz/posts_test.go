@@ -18,9 +18,12 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package nerdz_test
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/nerdzeu/nerdz-api/nerdz"
 )
@@ -196,3 +199,236 @@ func TestURL(t *testing.T) {
 		t.Fatalf("URL returned %s insted of Configuration.NERDZHost/admin.5", userPost.URL().String())
 	}
 }
+
+func TestHistory(t *testing.T) {
+	var post nerdz.UserPost
+	post.Message = "first version\nof the post"
+	if err := me.Add(&post); err != nil {
+		t.Fatalf("Add user post should work but, got: %v", err)
+	}
+	defer func() { _ = me.Delete(&post) }()
+
+	for _, message := range []string{"second version\nof the post", "third version\nof the post"} {
+		post.Message = message
+		if err := me.Edit(&post); err != nil {
+			t.Fatalf("Edit user post should work but, got: %v", err)
+		}
+	}
+
+	revisions := post.History()
+	if len(revisions) != int(post.RevisionsNumber()) {
+		t.Fatalf("Expected %d revisions, but got %d", post.RevisionsNumber(), len(revisions))
+	}
+	for i, revision := range revisions {
+		if i > 0 && revision.RevNo <= revisions[i-1].RevNo {
+			t.Errorf("Revisions are not ordered by revision number: %v", revisions)
+		}
+	}
+	if len(revisions) > 1 {
+		last := len(revisions) - 1
+		diff := nerdz.RevisionDiff(revisions[last-1].Message, revisions[last].Message, int(revisions[last].RevNo))
+		if !strings.Contains(diff, "+"+strings.Split(revisions[last].Message, "\n")[0]) {
+			t.Errorf("The diff should contain the added line, got: %s", diff)
+		}
+	}
+}
+
+func TestPins(t *testing.T) {
+	var posts []*nerdz.UserPost
+	for i := 0; i <= nerdz.MaxPinnedPosts; i++ {
+		post := &nerdz.UserPost{}
+		post.Message = fmt.Sprintf("pinned post %d", i)
+		if err := me.Add(post); err != nil {
+			t.Fatalf("Add user post should work but, got: %v", err)
+		}
+		defer func() { _ = me.Delete(post) }()
+		posts = append(posts, post)
+	}
+
+	if err := other.Pin(posts[0]); err == nil {
+		t.Errorf("Only the owner of the board should be able to pin")
+	}
+	for _, post := range posts[:nerdz.MaxPinnedPosts] {
+		if err := me.Pin(post); err != nil {
+			t.Fatalf("Pin should work, but got: %v", err)
+		}
+	}
+	if err := me.Pin(posts[nerdz.MaxPinnedPosts]); err == nil {
+		t.Errorf("A board should have at most %d pinned posts", nerdz.MaxPinnedPosts)
+	}
+	if pinned := me.PinnedPosts(); len(*pinned) != nerdz.MaxPinnedPosts {
+		t.Errorf("Expected %d pinned posts, but got %d", nerdz.MaxPinnedPosts, len(*pinned))
+	}
+
+	postlist := me.Postlist(nerdz.PostlistOptions{N: 1})
+	if len(*postlist) != 1 || (*postlist)[0].ID() != posts[nerdz.MaxPinnedPosts].ID() {
+		t.Errorf("The postlist should be ordered by creation, with the newest post first")
+	}
+
+	if err := me.Unpin(posts[0]); err != nil || posts[0].IsPinned() {
+		t.Fatalf("Unpin should work, but got: %v", err)
+	}
+	if err := me.Unpin(posts[0]); err == nil {
+		t.Errorf("Unpin of a not pinned post should fail")
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	post := &nerdz.UserPost{}
+	post.Message = "subscription test"
+	if err := me.Add(post); err != nil {
+		t.Fatalf("Add user post should work but, got: %v", err)
+	}
+	defer func() { _ = me.Delete(post) }()
+
+	subscription, err := me.Subscription(post)
+	if err != nil || subscription.Reason != nerdz.SubscriptionAuthor || !subscription.Subscribed() {
+		t.Fatalf("The author should be subscribed, but got: %+v, %v", subscription, err)
+	}
+	if subscription, err = other.Subscription(post); err != nil || subscription.Subscribed() {
+		t.Fatalf("The other user should not be subscribed, but got: %+v, %v", subscription, err)
+	}
+
+	if subscription, err = other.Subscribe(post); err != nil || subscription.Reason != nerdz.SubscriptionLurker {
+		t.Fatalf("Subscribe should lurk the post, but got: %+v, %v", subscription, err)
+	}
+	if subscription, err = other.Unsubscribe(post); err != nil || !subscription.Muted || subscription.Subscribed() {
+		t.Fatalf("Unsubscribe should mute the post, but got: %+v, %v", subscription, err)
+	}
+	if subscription, _ = other.Subscription(post); subscription.Reason != nerdz.SubscriptionLurker {
+		t.Errorf("Unsubscribe should keep lurking the post, but got: %+v", subscription)
+	}
+	for _, p := range *other.Subscriptions(nerdz.PostlistOptions{N: 20}) {
+		if p.Type == nerdz.UserPostID && p.Hpid == post.Hpid {
+			t.Errorf("The muted post should not be in the subscriptions")
+		}
+	}
+
+	if subscription, err = other.Subscribe(post); err != nil || !subscription.Subscribed() {
+		t.Fatalf("Subscribe should unmute the post, but got: %+v, %v", subscription, err)
+	}
+	_ = other.Unlurk(post)
+}
+
+func TestPostClosure(t *testing.T) {
+	post := &nerdz.UserPost{}
+	post.Message = "closure test"
+	if err := me.Add(post); err != nil {
+		t.Fatalf("Add user post should work but, got: %v", err)
+	}
+	defer func() { _ = me.Delete(post) }()
+
+	if err := other.Close(post); err == nil {
+		t.Errorf("Only the owners of the post should be able to close it")
+	}
+	if err := me.Close(post); err != nil || !post.IsClosed() {
+		t.Fatalf("Close should work, but got: %v", err)
+	}
+	if err := me.Close(post); err == nil {
+		t.Errorf("Close of a closed post should fail")
+	}
+	if closer := post.NumericCloser(); closer != me.ID() {
+		t.Errorf("The post should be closed by %d, but got %d", me.ID(), closer)
+	}
+	if to := post.GetTO(me); !to.Closed || to.ClosedBy == nil || to.ClosedBy.ID != me.ID() {
+		t.Errorf("The TO should report who closed the post, but got: %+v", to.ClosedBy)
+	}
+
+	if err := me.Reopen(post); err != nil || post.IsClosed() || post.NumericCloser() != 0 {
+		t.Fatalf("Reopen should work, but got: %v", err)
+	}
+	if err := me.Reopen(post); err == nil {
+		t.Errorf("Reopen of an open post should fail")
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	if err := me.SaveDraft(&nerdz.Draft{To: me.ID(), Type: nerdz.UserBoardID, Message: "  "}); err == nil {
+		t.Errorf("SaveDraft with an empty message should fail")
+	}
+	past := sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	if err := me.SaveDraft(&nerdz.Draft{To: me.ID(), Type: nerdz.UserBoardID, Message: "draft", PublishAt: past}); err == nil {
+		t.Errorf("SaveDraft with a past publication time should fail")
+	}
+
+	draft := nerdz.Draft{To: prj.ID(), Type: nerdz.ProjectBoardID, Message: "draft test"}
+	if err := me.SaveDraft(&draft); err != nil {
+		t.Fatalf("SaveDraft should work, but got: %v", err)
+	}
+	if drafts := me.Drafts(); len(drafts) == 0 || drafts[0].ID != draft.ID {
+		t.Errorf("The last updated draft should come first, but got: %+v", drafts)
+	}
+	if err := other.UpdateDraft(&draft); err == nil {
+		t.Errorf("Only the author should be able to update the draft")
+	}
+
+	draft.PublishAt = sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+	if err := me.UpdateDraft(&draft); err != nil {
+		t.Fatalf("UpdateDraft should work, but got: %v", err)
+	}
+	if saved, err := nerdz.NewDraft(draft.ID); err != nil || !saved.PublishAt.Valid {
+		t.Errorf("The draft should be scheduled, but got: %+v, %v", saved, err)
+	}
+
+	post, err := me.PublishDraft(&draft)
+	if err != nil {
+		t.Fatalf("PublishDraft should work, but got: %v", err)
+	}
+	defer func() { _ = me.Delete(post) }()
+	if projectPost, ok := post.(*nerdz.ProjectPost); !ok || projectPost.Message != "draft test" || projectPost.To != prj.ID() {
+		t.Errorf("The published post should have the message and the board of the draft, but got: %+v", post)
+	}
+	if _, err := nerdz.NewDraft(draft.ID); err == nil {
+		t.Errorf("The published draft should be deleted")
+	}
+	if err := me.UpdateDraft(&draft); err == nil {
+		t.Errorf("UpdateDraft of a published draft should fail")
+	}
+	if _, err := me.PublishDraft(&draft); err == nil {
+		t.Errorf("PublishDraft of a published draft should fail")
+	}
+}
+
+func TestPublishScheduledPosts(t *testing.T) {
+	message := fmt.Sprintf("scheduled post %d", time.Now().UnixNano())
+	draft := nerdz.Draft{To: me.ID(), Type: nerdz.UserBoardID, Message: message,
+		PublishAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}}
+	if err := me.SaveDraft(&draft); err != nil {
+		t.Fatalf("SaveDraft should work, but got: %v", err)
+	}
+	// make the scheduled post due
+	if err := nerdz.Db().Exec(`UPDATE drafts SET publish_at = (now() at time zone 'utc') - interval '1 minute' WHERE id = ?`, draft.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		nerdz.PublishScheduledPosts(ctx, 50*time.Millisecond)
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := nerdz.NewDraft(draft.ID); err != nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	// more ticks of the scheduler, that must not publish the post again
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+
+	if _, err := nerdz.NewDraft(draft.ID); err == nil {
+		t.Fatalf("The published scheduled post should be deleted from the drafts")
+	}
+	var posts []nerdz.UserPost
+	if err := nerdz.Db().Model(nerdz.UserPost{}).Where(`"to" = ? AND message = ?`, me.ID(), message).Scan(&posts); err != nil {
+		t.Fatalf("The scheduled post should be published, but got: %v", err)
+	}
+	for i := range posts {
+		_ = me.Delete(&posts[i])
+	}
+	if len(posts) != 1 {
+		t.Errorf("The scheduled post should be published exactly once, but got %d posts", len(posts))
+	}
+}
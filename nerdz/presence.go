@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+const (
+	// OnlineTimeout is the time, since the last activity, after which a user is offline
+	OnlineTimeout = 5 * time.Minute
+	// presenceRefresh is the minimum time between two updates of the last activity of a user
+	presenceRefresh = time.Minute
+)
+
+// Presence returns the online status of the user.
+// A user that hides the online status is always offline, with no last activity
+func (user *User) Presence() *Presence {
+	if !user.Viewonline {
+		return &Presence{User: user.ID()}
+	}
+	return &Presence{
+		User:   user.ID(),
+		Online: time.Since(user.Last) < OnlineTimeout,
+		Last:   user.Last,
+	}
+}
+
+// Touch records the activity of the user. The last activity is stored at most once every presenceRefresh.
+// When the user comes back online, the user ID is sent on the presence channel
+func (user *User) Touch() error {
+	now := time.Now().UTC()
+	if now.Sub(user.Last) < presenceRefresh {
+		return nil
+	}
+	if err := TouchUser(user.ID()); err != nil {
+		return err
+	}
+	user.Last = now
+	return nil
+}
+
+// TouchUser records the activity of the user with the specified ID, without loading the user.
+// The last activity is stored at most once every presenceRefresh.
+// When the user comes back online, the user ID is sent on the presence channel
+func TouchUser(id uint64) error {
+	now := time.Now().UTC()
+	var last time.Time
+	var viewonline bool
+	if err := Db().Raw(`UPDATE users u SET "last" = ?
+	FROM (SELECT counter, "last", viewonline FROM users WHERE counter = ? FOR UPDATE) old
+	WHERE u.counter = old.counter AND old."last" < ?
+	RETURNING old."last", old.viewonline`, now, id, now.Add(-presenceRefresh)).Scan(&last, &viewonline); err != nil {
+		// no rows: the last activity is recent
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	if now.Sub(last) >= OnlineTimeout && viewonline {
+		notifyEvent(PresenceChannel, id)
+	}
+	return nil
+}
+
+// TrackPresence sends on the presence channel the users that went offline, checking every period,
+// until the context is done. The users that hide the online status are not sent
+func TrackPresence(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	since := time.Now().UTC().Add(-OnlineTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		until := time.Now().UTC().Add(-OnlineTimeout)
+		var offline []uint64
+		if err := Db().Model(User{}).Where(
+			`viewonline AND "last" >= ? AND "last" < ?`, since, until).Pluck("counter", &offline); err != nil && err != sql.ErrNoRows {
+			log.Errorf("(TrackPresence) Error in query.Pluck: %s", err)
+			continue
+		}
+		for _, user := range offline {
+			notifyEvent(PresenceChannel, user)
+		}
+		since = until
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	// the timezones are validated against the embedded tz database,
+	// thus the validation doesn't depend on the tz database of the host
+	_ "time/tzdata"
+	"unicode/utf8"
+
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+const (
+	// maxNameLength is the maximum length of the name and of the surname of a user
+	maxNameLength = 60
+	// maxDateformatLength is the maximum length of the date format of a user
+	maxDateformatLength = 25
+)
+
+// UpdateInfo validates the editable fields of the update (name, surname, timezone,
+// language, board language and online status visibility) and stores them as the user fields.
+// Only the fields changed by the update are validated, thus a stored value that's not valid anymore
+// doesn't prevent the update of the other fields. The other fields of the update are ignored
+func (user *User) UpdateInfo(update *User) error {
+	if update.Name != user.Name {
+		update.Name = strings.TrimSpace(update.Name)
+		if err := validateName("name", update.Name); err != nil {
+			return err
+		}
+	}
+	if update.Surname != user.Surname {
+		update.Surname = strings.TrimSpace(update.Surname)
+		if err := validateName("surname", update.Surname); err != nil {
+			return err
+		}
+	}
+	if update.Timezone != user.Timezone {
+		if err := validateTimezone(update.Timezone); err != nil {
+			return err
+		}
+	}
+	if update.Lang != user.Lang && !utils.InSlice(update.Lang, Configuration.Languages) {
+		return errors.New("invalid language: " + update.Lang)
+	}
+	if update.BoardLang != user.BoardLang && !utils.InSlice(update.BoardLang, Configuration.Languages) {
+		return errors.New("invalid board language: " + update.BoardLang)
+	}
+
+	if err := Db().Exec(`UPDATE users SET name = ?, surname = ?, timezone = ?, lang = ?, board_lang = ?, viewonline = ? WHERE counter = ?`,
+		update.Name, update.Surname, update.Timezone, update.Lang, update.BoardLang, update.Viewonline, user.ID()); err != nil {
+		return err
+	}
+
+	// the followers see the online status appear or disappear
+	presenceChanged := user.Viewonline != update.Viewonline
+	user.Name = update.Name
+	user.Surname = update.Surname
+	user.Timezone = update.Timezone
+	user.Lang = update.Lang
+	user.BoardLang = update.BoardLang
+	user.Viewonline = update.Viewonline
+	if presenceChanged {
+		notifyEvent(PresenceChannel, user.ID())
+	}
+	return nil
+}
+
+// UpdateProfile validates the editable fields of the profile (biography, quotes, website,
+// GitHub, Telegram, template and date format) and stores them as the user profile fields.
+// Only the fields changed by the profile are validated, thus a stored value that's not valid anymore
+// doesn't prevent the update of the other fields. The other fields of the profile are ignored
+func (user *User) UpdateProfile(profile *Profile) error {
+	if profile.Website != user.Profile.Website {
+		profile.Website = strings.TrimSpace(profile.Website)
+		if err := validateURL("website", profile.Website); err != nil {
+			return err
+		}
+	}
+	if profile.Github != user.Profile.Github {
+		profile.Github = strings.TrimSpace(profile.Github)
+		if err := validateURL("github", profile.Github, "github.com", "www.github.com"); err != nil {
+			return err
+		}
+	}
+	if profile.Telegram != user.Profile.Telegram {
+		profile.Telegram = strings.TrimSpace(profile.Telegram)
+		if err := validateURL("telegram", profile.Telegram, "t.me", "telegram.me"); err != nil {
+			return err
+		}
+	}
+	if profile.Template != user.Profile.Template {
+		if _, ok := Configuration.Templates[profile.Template]; !ok {
+			return errors.New("invalid template")
+		}
+	}
+	if profile.Dateformat != user.Profile.Dateformat {
+		profile.Dateformat = strings.TrimSpace(profile.Dateformat)
+		if profile.Dateformat == "" || utf8.RuneCountInString(profile.Dateformat) > maxDateformatLength {
+			return errors.New("the date format must be not empty and at most 25 characters long")
+		}
+	}
+
+	if err := Db().Exec(`UPDATE profiles SET biography = ?, quotes = ?, website = ?, github = ?, telegram = ?, template = ?, dateformat = ? WHERE counter = ?`,
+		profile.Biography, profile.Quotes, profile.Website, profile.Github, profile.Telegram, profile.Template, profile.Dateformat, user.ID()); err != nil {
+		return err
+	}
+
+	user.Profile.Biography = profile.Biography
+	user.Profile.Quotes = profile.Quotes
+	user.Profile.Website = profile.Website
+	user.Profile.Github = profile.Github
+	user.Profile.Telegram = profile.Telegram
+	user.Profile.Template = profile.Template
+	user.Profile.Dateformat = profile.Dateformat
+	return nil
+}
+
+// validateName returns an error if the value of the field is empty or too long
+func validateName(field, value string) error {
+	if value == "" || utf8.RuneCountInString(value) > maxNameLength {
+		return errors.New("the " + field + " must be not empty and at most 60 characters long")
+	}
+	return nil
+}
+
+// validateTimezone returns an error if the timezone is not in the tz database
+func validateTimezone(timezone string) error {
+	// LoadLocation accepts "" and "Local" too, that are not timezones of the tz database
+	if timezone == "" || timezone == "Local" {
+		return errors.New("invalid timezone: " + timezone)
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return errors.New("invalid timezone: " + timezone)
+	}
+	return nil
+}
+
+// validateURL returns an error if the value of the field is not empty and is not an absolute
+// http or https URL. If hosts are specified, the URL host must be one of them
+func validateURL(field, value string, hosts ...string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the " + field + " must be an absolute http or https URL")
+	}
+	if len(hosts) > 0 && !utils.InSlice(strings.ToLower(u.Host), hosts) {
+		return errors.New("the " + field + " URL must be on " + strings.Join(hosts, " or "))
+	}
+	return nil
+}
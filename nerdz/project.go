@@ -18,6 +18,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package nerdz
 
 import (
+	"errors"
 	"net/url"
 
 	"github.com/labstack/gommon/log"
@@ -46,6 +47,18 @@ func NewProject(id uint64) (*Project, error) {
 	return NewProjectWhere(&Project{Counter: id})
 }
 
+// NewProjectByName returns the project with the specified name (case insensitive)
+func NewProjectByName(name string) (*Project, error) {
+	var counters []uint64
+	if err := Db().Model(Project{}).Where("LOWER(name) = LOWER(?)", name).Pluck("counter", &counters); err != nil {
+		return nil, err
+	}
+	if len(counters) == 0 {
+		return nil, errors.New("project " + name + " does not exist")
+	}
+	return NewProject(counters[0])
+}
+
 // NewProjectWhere returns the first user that matches the description
 func NewProjectWhere(description *Project) (project *Project, e error) {
 	project = new(Project)
@@ -138,6 +151,11 @@ func (prj *Project) Info() *Info {
 
 // Postlist returns the specified posts on the project
 func (prj *Project) Postlist(options PostlistOptions) *[]ExistingPost {
+	var retPosts []ExistingPost
+	if options.Viewer != nil && !options.Viewer.CanSee(prj) {
+		return &retPosts
+	}
+
 	var posts []ProjectPost
 	var projectPost ProjectPost
 	projectPosts := projectPost.TableName()
@@ -153,7 +171,6 @@ func (prj *Project) Postlist(options PostlistOptions) *[]ExistingPost {
 		log.Errorf("(Postlist) Error in query.Scan: %s", err)
 	}
 
-	var retPosts []ExistingPost
 	for _, p := range posts {
 		projectPost := p
 		retPosts = append(retPosts, ExistingPost(&projectPost))
@@ -0,0 +1,155 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxProjectNameLength is the maximum length of the name of a project
+	maxProjectNameLength = 30
+	// pqUniqueViolation is the code of the error raised by postgres when a unique constraint is violated
+	pqUniqueViolation = "23505"
+)
+
+// projectNameRegexp matches the valid project names.
+// The name identifies the project in the URLs, thus spaces and symbols are not allowed
+var projectNameRegexp = regexp.MustCompile(`^[\p{L}\p{N}_.\-]+$`)
+
+// validateProject validates the editable fields of the project (description, goal, website and photo)
+func validateProject(project *Project) error {
+	project.Description = strings.TrimSpace(project.Description)
+	project.Goal = strings.TrimSpace(project.Goal)
+	project.Website.String = strings.TrimSpace(project.Website.String)
+	project.Photo.String = strings.TrimSpace(project.Photo.String)
+	project.Website.Valid = project.Website.String != ""
+	project.Photo.Valid = project.Photo.String != ""
+
+	if project.Description == "" {
+		return errors.New("the description must be not empty")
+	}
+	if err := validateURL("website", project.Website.String); err != nil {
+		return err
+	}
+	return validateURL("photo", project.Photo.String)
+}
+
+// CreateProject creates the project, owned by the user. The project name must be unique (case insensitive)
+func (user *User) CreateProject(project *Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if utf8.RuneCountInString(project.Name) > maxProjectNameLength || !projectNameRegexp.MatchString(project.Name) {
+		return errors.New("the name must be not empty, at most 30 characters long and must contain only letters, numbers, '_', '-' and '.'")
+	}
+	if err := validateProject(project); err != nil {
+		return err
+	}
+
+	tx := Db().Begin()
+	created := Project{Name: project.Name, Description: project.Description, Goal: project.Goal, Website: project.Website, Photo: project.Photo}
+	if err := tx.Create(&created); err != nil {
+		_ = tx.Rollback()
+		// the unique index on the lowercase name rejects the existing names
+		if pqErr := new(pq.Error); errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+			return fmt.Errorf("the project %s already exists", project.Name)
+		}
+		return err
+	}
+	// Create skips the false values: the flags are always stored
+	if err := tx.Exec(`UPDATE groups SET private = ?, visible = ?, open = ? WHERE counter = ?`,
+		project.Private, project.Visible, project.Open, created.Counter); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Create(&ProjectOwner{From: user.ID(), To: created.Counter}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	project.Counter = created.Counter
+	project.CreationTime = created.CreationTime
+	return nil
+}
+
+// UpdateProject validates the editable fields of the update (description, goal, website, photo,
+// visibility, privacy and openness) and stores them as the project fields. Only the owner and the admins can update the project.
+// The other fields of the update are ignored
+func (user *User) UpdateProject(project, update *Project) error {
+	if !project.HasPermission(user, ProjectPermissionUpdate) {
+		return errors.New("only the owner and the admins can update the project")
+	}
+	if err := validateProject(update); err != nil {
+		return err
+	}
+
+	if err := Db().Exec(`UPDATE groups SET description = ?, goal = ?, website = ?, photo = ?, private = ?, visible = ?, open = ? WHERE counter = ?`,
+		update.Description, update.Goal, update.Website, update.Photo, update.Private, update.Visible, update.Open, project.ID()); err != nil {
+		return err
+	}
+
+	project.Description = update.Description
+	project.Goal = update.Goal
+	project.Website = update.Website
+	project.Photo = update.Photo
+	project.Private = update.Private
+	project.Visible = update.Visible
+	project.Open = update.Open
+	return nil
+}
+
+// TransferProject makes the other user the owner of the project. Only the owner can transfer the project.
+// The membership, or the pending membership request, of the other user is replaced by the ownership
+func (user *User) TransferProject(project *Project, other *User) error {
+	if !project.IsOwnedBy(user) {
+		return errors.New("only the owner can transfer the project")
+	}
+	if other.ID() == user.ID() {
+		return errors.New("you already own the project")
+	}
+
+	tx := Db().Begin()
+	for _, table := range []string{ProjectMember{}.TableName(), ProjectMembershipRequest{}.TableName()} {
+		if err := tx.Exec(`DELETE FROM `+table+` WHERE "from" = ? AND "to" = ?`, other.ID(), project.ID()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Exec(`UPDATE groups_owners SET "from" = ?, "time" = (now() at time zone 'utc'), to_notify = TRUE WHERE "to" = ?`,
+		other.ID(), project.ID()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProject deletes the project, with its posts. Only the owner can delete the project
+func (user *User) DeleteProject(project *Project) error {
+	if !project.IsOwnedBy(user) {
+		return errors.New("only the owner can delete the project")
+	}
+	return Db().Delete(&Project{Counter: project.ID()})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	// ExportCSV is the format of the exports with a header row and a comma separated row per user
+	ExportCSV = "csv"
+	// ExportNDJSON is the format of the exports with a JSON object per line
+	ExportNDJSON = "ndjson"
+
+	// exportBatchSize is the number of users read from the database at a time,
+	// thus the memory used by an export doesn't depend on the number of exported users
+	exportBatchSize = 500
+)
+
+// ExportFormats are the formats of the exports of the followers and the members of a project
+var ExportFormats = []string{ExportCSV, ExportNDJSON}
+
+// ProjectExportUser is a follower, or a member, of a project in an export
+type ProjectExportUser struct {
+	ID       uint64
+	Username string
+	Joined   time.Time    // time of following, or of joining, the project
+	Contacts *ContactInfo // nil when the contact info are not exported
+}
+
+// GetTO returns its Transfer Object
+func (u *ProjectExportUser) GetTO(users ...*User) *ProjectExportUserTO {
+	to := &ProjectExportUserTO{
+		original:  u,
+		ID:        u.ID,
+		Username:  u.Username,
+		Joined:    u.Joined,
+		Timestamp: u.Joined.Unix(),
+	}
+	if u.Contacts != nil {
+		to.Contacts = u.Contacts.GetTO()
+	}
+	return to
+}
+
+// projectExportRow is a row of a batch of an export
+type projectExportRow struct {
+	Counter  uint64 // counter of the relation, used to read the next batch
+	ID       uint64
+	Username string
+	Joined   time.Time
+	Website  string
+	Github   string
+	Skype    string
+	Jabber   string
+	Telegram string
+	Facebook string
+	Twitter  string
+	Steam    string
+}
+
+// ExportFollowers calls write for every follower of the project, in order of following.
+// The contact info of the followers are exported only when contacts, the user that receives them, is not nil
+func (prj *Project) ExportFollowers(contacts *User, write func(*ProjectExportUser) error) error {
+	return prj.export(ProjectFollower{}.TableName(), contacts, write)
+}
+
+// ExportMembers calls write for every member of the project, in order of joining.
+// The contact info of the members are exported only when contacts, the user that receives them, is not nil
+func (prj *Project) ExportMembers(contacts *User, write func(*ProjectExportUser) error) error {
+	return prj.export(ProjectMember{}.TableName(), contacts, write)
+}
+
+// export reads the users related to the project through the table in batches, and calls write for every user.
+// The contact info of the users that blacklisted contacts, or whose private board contacts can't see, are empty
+func (prj *Project) export(table string, contacts *User, write func(*ProjectExportUser) error) error {
+	if write == nil {
+		return errors.New("unable to export to undefined writer")
+	}
+
+	var viewer uint64
+	if contacts != nil {
+		viewer = contacts.ID()
+	}
+
+	var last uint64
+	for {
+		var rows []projectExportRow
+		if err := Db().Raw(`SELECT r.counter, u.counter, u.username, r."time",
+		COALESCE(p.website, ''), COALESCE(p.github, ''), COALESCE(p.skype, ''), COALESCE(p.jabber, ''),
+		COALESCE(p.telegram, ''), COALESCE(p.facebook, ''), COALESCE(p.twitter, ''), COALESCE(p.steam, '')
+		FROM `+table+` r
+		JOIN users u ON u.counter = r."from"
+		CROSS JOIN LATERAL (SELECT u.counter AS "to") t
+		LEFT JOIN profiles p ON p.counter = u.counter
+			AND u.counter NOT IN (SELECT "from" FROM blacklist WHERE "to" = ?)
+			AND `+privateBoardVisibility+`
+		WHERE r."to" = ? AND r.counter > ?
+		ORDER BY r.counter
+		LIMIT ?`, viewer, viewer, viewer, prj.ID(), last, exportBatchSize).Scan(&rows); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			user := ProjectExportUser{ID: row.ID, Username: row.Username, Joined: row.Joined}
+			if contacts != nil {
+				user.Contacts = contactInfo(&Profile{
+					Website:  row.Website,
+					Github:   row.Github,
+					Skype:    row.Skype,
+					Jabber:   row.Jabber,
+					Telegram: row.Telegram,
+					Facebook: row.Facebook,
+					Twitter:  row.Twitter,
+					Steam:    row.Steam,
+				})
+			}
+			if err := write(&user); err != nil {
+				return err
+			}
+			last = row.Counter
+		}
+
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+	}
+}
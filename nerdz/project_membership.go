@@ -0,0 +1,258 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/galeone/igor"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// Kinds of the pending project membership requests
+const (
+	// ProjectInvite is an invitation to join the project, sent by a member or by the owner
+	ProjectInvite = "invite"
+	// ProjectJoinRequest is a request to join the closed project, sent by the user
+	ProjectJoinRequest = "request"
+)
+
+// Reasons of the project notifications
+const (
+	// ProjectNotifyPost notifies a new post on the project
+	ProjectNotifyPost = "post"
+	// ProjectNotifyInvite notifies the invitation to join the project
+	ProjectNotifyInvite = "invite"
+	// ProjectNotifyRequest notifies the owner of a new join request
+	ProjectNotifyRequest = "request"
+	// ProjectNotifyJoin notifies the owner of a new member
+	ProjectNotifyJoin = "join"
+	// ProjectNotifyAccept notifies the user that the join request has been approved
+	ProjectNotifyAccept = "accept"
+	// ProjectNotifyDeny notifies the user that the join request has been denied
+	ProjectNotifyDeny = "deny"
+	// ProjectNotifyLeave notifies the owner that a member left the project
+	ProjectNotifyLeave = "leave"
+	// ProjectNotifyKick notifies the user of the removal from the project
+	ProjectNotifyKick = "kick"
+)
+
+// HasMember returns true if the user is a member of the project. The owner is not a member
+func (prj *Project) HasMember(user *User) bool {
+	return utils.InSlice(user.ID(), prj.NumericMembers())
+}
+
+// IsOwnedBy returns true if the user is the owner of the project
+func (prj *Project) IsOwnedBy(user *User) bool {
+	return prj.NumericOwner() == user.ID()
+}
+
+// MembershipRequests returns the pending membership requests of the kind (ProjectInvite or ProjectJoinRequest),
+// from the oldest
+func (prj *Project) MembershipRequests(kind string) (requests []ProjectMembershipRequest) {
+	_ = Db().Model(ProjectMembershipRequest{}).Where(&ProjectMembershipRequest{To: prj.ID(), Kind: kind}).Order("counter").Scan(&requests)
+	return
+}
+
+// membershipRequest returns the pending membership request of the user
+func (prj *Project) membershipRequest(user *User) (*ProjectMembershipRequest, error) {
+	var requests []ProjectMembershipRequest
+	if err := Db().Model(ProjectMembershipRequest{}).Where(&ProjectMembershipRequest{From: user.ID(), To: prj.ID()}).Scan(&requests); err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%s has no pending invitation or join request", user.Username)
+	}
+	return &requests[0], nil
+}
+
+// notifyMembership stores, using the tx transaction, the notification for the user to
+// of the membership change of the project, about the subject user
+func notifyMembership(tx *igor.Database, project *Project, to uint64, reason string, subject *User) error {
+	return tx.Create(&ProjectNotify{From: project.ID(), To: to, Reason: reason, User: subject.ID()})
+}
+
+// addProjectMember makes, atomically, the user a member of the project: the pending membership request
+// is deleted and the notification with the reason is sent to the user to
+func addProjectMember(project *Project, user *User, to uint64, reason string) error {
+	tx := Db().Begin()
+	if err := tx.Exec(`DELETE FROM groups_membership_requests WHERE "from" = ? AND "to" = ?`, user.ID(), project.ID()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Create(&ProjectMember{From: user.ID(), To: project.ID()}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := notifyMembership(tx, project, to, reason, user); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// removeProjectMembership deletes, atomically, the membership or the pending membership request
+// of the user, and sends the notification with the reason to the user to
+func removeProjectMembership(project *Project, user *User, to uint64, reason string) error {
+	tx := Db().Begin()
+	for _, table := range []string{ProjectMember{}.TableName(), ProjectMembershipRequest{}.TableName()} {
+		if err := tx.Exec(`DELETE FROM `+table+` WHERE "from" = ? AND "to" = ?`, user.ID(), project.ID()); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := notifyMembership(tx, project, to, reason, user); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkNotInProject returns an error if the user is already in the project
+func checkNotInProject(project *Project, user *User) error {
+	if project.IsOwnedBy(user) || project.HasMember(user) {
+		return fmt.Errorf("%s is already in the project", user.Username)
+	}
+	return nil
+}
+
+// JoinProject makes the user a member of the project, if the project is open or the user has been invited.
+// Otherwise a join request is sent to the owner of the project
+func (user *User) JoinProject(project *Project) error {
+	if err := checkNotInProject(project, user); err != nil {
+		return err
+	}
+	owner := project.Owner()
+	if owner == nil {
+		return errors.New("the project has no owner")
+	}
+	if utils.InSlice(user.ID(), owner.NumericBlacklist()) {
+		return errors.New("the owner of the project blacklisted you")
+	}
+
+	request, err := project.membershipRequest(user)
+	if project.Open || (err == nil && request.Kind == ProjectInvite) {
+		return addProjectMember(project, user, owner.ID(), ProjectNotifyJoin)
+	}
+	if err == nil {
+		return errors.New("you already sent a join request")
+	}
+
+	tx := Db().Begin()
+	if err := tx.Create(&ProjectMembershipRequest{From: user.ID(), To: project.ID(), Kind: ProjectJoinRequest, Sender: user.ID()}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := notifyMembership(tx, project, owner.ID(), ProjectNotifyRequest, user); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// LeaveProject removes the user from the members of the project, or deletes the pending invitation
+// or join request of the user. The owner can't leave the project, but can transfer it
+func (user *User) LeaveProject(project *Project) error {
+	if project.IsOwnedBy(user) {
+		return errors.New("the owner can't leave the project: transfer it first")
+	}
+	if project.HasMember(user) {
+		return removeProjectMembership(project, user, project.NumericOwner(), ProjectNotifyLeave)
+	}
+	if _, err := project.membershipRequest(user); err != nil {
+		return errors.New("you are not a member of the project")
+	}
+	return Db().Exec(`DELETE FROM groups_membership_requests WHERE "from" = ? AND "to" = ?`, user.ID(), project.ID())
+}
+
+// InviteToProject invites the other user to join the project. The owner, the admins and the moderators can invite.
+// If the other user already sent a join request, the invitation approves it: thus only the owner
+// and the admins, that can approve the join requests, can invite the users that requested to join
+func (user *User) InviteToProject(project *Project, other *User) error {
+	if !project.HasPermission(user, ProjectPermissionInvite) {
+		return errors.New("only the owner, the admins and the moderators can invite to the project")
+	}
+	if err := checkNotInProject(project, other); err != nil {
+		return err
+	}
+	if utils.InSlice(user.ID(), other.NumericBlacklist()) {
+		return fmt.Errorf("%s blacklisted you", other.Username)
+	}
+
+	request, err := project.membershipRequest(other)
+	if err == nil && request.Kind == ProjectJoinRequest {
+		if !project.HasPermission(user, ProjectPermissionManageMembers) {
+			return fmt.Errorf("%s requested to join the project: only the owner and the admins can approve the join requests", other.Username)
+		}
+		return addProjectMember(project, other, other.ID(), ProjectNotifyAccept)
+	}
+	if err == nil {
+		return fmt.Errorf("%s has already been invited", other.Username)
+	}
+
+	tx := Db().Begin()
+	if err := tx.Create(&ProjectMembershipRequest{From: other.ID(), To: project.ID(), Kind: ProjectInvite, Sender: user.ID()}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := notifyMembership(tx, project, other.ID(), ProjectNotifyInvite, user); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApproveProjectRequest approves the join request of the other user, that becomes a member.
+// Only the owner and the admins can approve the join requests
+func (user *User) ApproveProjectRequest(project *Project, other *User) error {
+	if !project.HasPermission(user, ProjectPermissionManageMembers) {
+		return errors.New("only the owner and the admins can approve the join requests")
+	}
+	if request, err := project.membershipRequest(other); err != nil || request.Kind != ProjectJoinRequest {
+		return fmt.Errorf("%s didn't request to join the project", other.Username)
+	}
+	return addProjectMember(project, other, other.ID(), ProjectNotifyAccept)
+}
+
+// DenyProjectRequest denies the join request of the other user.
+// Only the owner and the admins can deny the join requests
+func (user *User) DenyProjectRequest(project *Project, other *User) error {
+	if !project.HasPermission(user, ProjectPermissionManageMembers) {
+		return errors.New("only the owner and the admins can deny the join requests")
+	}
+	if request, err := project.membershipRequest(other); err != nil || request.Kind != ProjectJoinRequest {
+		return fmt.Errorf("%s didn't request to join the project", other.Username)
+	}
+	return removeProjectMembership(project, other, other.ID(), ProjectNotifyDeny)
+}
+
+// KickFromProject removes the other user from the members of the project.
+// Only the owner and the admins can remove the members, with a lower role
+func (user *User) KickFromProject(project *Project, other *User) error {
+	if !project.HasPermission(user, ProjectPermissionManageMembers) {
+		return errors.New("only the owner and the admins can remove the members")
+	}
+	if !project.HasMember(other) {
+		return fmt.Errorf("%s is not a member of the project", other.Username)
+	}
+	if err := checkProjectRank(project, user, other); err != nil {
+		return err
+	}
+	return removeProjectMembership(project, other, other.ID(), ProjectNotifyKick)
+}
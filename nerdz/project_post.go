@@ -110,12 +110,13 @@ func (post *ProjectPost) IsClosed() bool {
 	return post.Closed
 }
 
-// NumericOwners returns a slice of ids of the owner of the posts (the ones that can perform actions)
+// NumericOwners returns a slice of ids of the owner of the posts (the ones that can perform actions):
+// the sender and the users with the permission to moderate the project
 func (post *ProjectPost) NumericOwners() (ret []uint64) {
 	ret = append(ret, post.From)
-	project, _ := NewProject(post.To)
-	ret = append(ret, project.NumericOwner())
-	ret = append(ret, project.NumericMembers()...)
+	if project, err := NewProject(post.To); err == nil {
+		ret = append(ret, project.NumericUsersWithPermission(ProjectPermissionModerate)...)
+	}
 	return
 }
 
@@ -147,6 +148,12 @@ func (post *ProjectPost) Revisions() (modifications []string) {
 	return
 }
 
+// History returns the revisions of the message, from the first one
+func (post *ProjectPost) History() (revisions []ProjectPostRevision) {
+	_ = Db().Model(ProjectPostRevision{}).Where(&ProjectPostRevision{Hpid: post.ID()}).Order("rev_no").Scan(&revisions)
+	return
+}
+
 // RevisionsNumber returns the number of the revisions
 func (post *ProjectPost) RevisionsNumber() (count uint8) {
 	_ = Db().Model(ProjectPostRevision{}).Where(&ProjectPostRevision{Hpid: post.ID()}).Count(&count)
@@ -151,9 +151,14 @@ func (comment *ProjectPostComment) IsEditable() bool {
 	return comment.Editable
 }
 
-// NumericOwners returns a slice of ids of the owner of the comment (the ones that can perform actions)
-func (comment *ProjectPostComment) NumericOwners() []uint64 {
-	return []uint64{comment.From, comment.To}
+// NumericOwners returns a slice of ids of the owner of the comment (the ones that can perform actions):
+// the sender and the users with the permission to moderate the project
+func (comment *ProjectPostComment) NumericOwners() (ret []uint64) {
+	ret = append(ret, comment.From)
+	if project, err := NewProject(comment.To); err == nil {
+		ret = append(ret, project.NumericUsersWithPermission(ProjectPermissionModerate)...)
+	}
+	return
 }
 
 // Owners returns a slice of *User representing the users who own the comment
@@ -167,6 +172,12 @@ func (comment *ProjectPostComment) Revisions() (modifications []string) {
 	return
 }
 
+// History returns the revisions of the message, from the first one
+func (comment *ProjectPostComment) History() (revisions []ProjectPostCommentRevision) {
+	_ = Db().Model(ProjectPostCommentRevision{}).Where(&ProjectPostCommentRevision{Hcid: comment.Hcid}).Order("rev_no").Scan(&revisions)
+	return
+}
+
 // RevisionsNumber returns the number of the revisions
 func (comment *ProjectPostComment) RevisionsNumber() (count uint8) {
 	_ = Db().Model(ProjectPostCommentRevision{}).Where(&ProjectPostCommentRevision{Hcid: comment.Hcid}).Count(&count)
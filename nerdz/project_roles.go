@@ -0,0 +1,171 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// Roles of the users in a project, from the highest
+const (
+	// ProjectRoleOwner is the role of the owner of the project
+	ProjectRoleOwner = "owner"
+	// ProjectRoleAdmin is the role of the members that manage the project together with the owner
+	ProjectRoleAdmin = "admin"
+	// ProjectRoleModerator is the role of the members that moderate the content of the project
+	ProjectRoleModerator = "moderator"
+	// ProjectRoleMember is the role of the members that write on the project board
+	ProjectRoleMember = "member"
+	// ProjectRoleNone is the role of the users that are not in the project
+	ProjectRoleNone = ""
+)
+
+// ProjectPermission is a permission of a role in a project
+type ProjectPermission string
+
+// Permissions of the roles in a project
+const (
+	// ProjectPermissionUpdate is the permission to update the information of the project
+	ProjectPermissionUpdate ProjectPermission = "update"
+	// ProjectPermissionManageRoles is the permission to change the roles of the members with a lower role
+	ProjectPermissionManageRoles ProjectPermission = "manage_roles"
+	// ProjectPermissionManageMembers is the permission to approve and deny the join requests,
+	// and to remove the members with a lower role
+	ProjectPermissionManageMembers ProjectPermission = "manage_members"
+	// ProjectPermissionInvite is the permission to invite the users to join the project
+	ProjectPermissionInvite ProjectPermission = "invite"
+	// ProjectPermissionEdit is the permission to edit the posts and the comments of the other users
+	ProjectPermissionEdit ProjectPermission = "edit"
+	// ProjectPermissionModerate is the permission to close and delete the posts,
+	// and to delete the comments, of the other users
+	ProjectPermissionModerate ProjectPermission = "moderate"
+	// ProjectPermissionComment is the permission to comment the closed posts
+	ProjectPermissionComment ProjectPermission = "comment"
+	// ProjectPermissionPin is the permission to pin and unpin the posts to the top of the project board
+	ProjectPermissionPin ProjectPermission = "pin"
+	// ProjectPermissionExport is the permission to export the followers and the members of the project
+	ProjectPermissionExport ProjectPermission = "export"
+)
+
+// ProjectPermissions is the permission matrix: the permissions of every role.
+// Deleting and transferring the project is a prerogative of the owner
+var ProjectPermissions = map[string][]ProjectPermission{
+	ProjectRoleOwner: {ProjectPermissionUpdate, ProjectPermissionManageRoles, ProjectPermissionManageMembers,
+		ProjectPermissionInvite, ProjectPermissionEdit, ProjectPermissionModerate, ProjectPermissionComment, ProjectPermissionPin, ProjectPermissionExport},
+	ProjectRoleAdmin: {ProjectPermissionUpdate, ProjectPermissionManageRoles, ProjectPermissionManageMembers,
+		ProjectPermissionInvite, ProjectPermissionEdit, ProjectPermissionModerate, ProjectPermissionComment, ProjectPermissionPin, ProjectPermissionExport},
+	ProjectRoleModerator: {ProjectPermissionInvite, ProjectPermissionModerate, ProjectPermissionComment, ProjectPermissionPin},
+	ProjectRoleMember:    {},
+}
+
+// projectRoleRanks are the ranks of the roles: a role can manage only the roles with a lower rank
+var projectRoleRanks = map[string]int{
+	ProjectRoleOwner:     4,
+	ProjectRoleAdmin:     3,
+	ProjectRoleModerator: 2,
+	ProjectRoleMember:    1,
+	ProjectRoleNone:      0,
+}
+
+// Memberships returns the memberships of the members of the project, with their roles
+func (prj *Project) Memberships() (members []ProjectMember) {
+	_ = Db().Model(ProjectMember{}).Where(&ProjectMember{To: prj.ID()}).Order("counter").Scan(&members)
+	return
+}
+
+// Role returns the role of the user in the project, ProjectRoleNone if the user is not in the project
+func (prj *Project) Role(user *User) string {
+	if prj.IsOwnedBy(user) {
+		return ProjectRoleOwner
+	}
+	var roles []string
+	_ = Db().Model(ProjectMember{}).Where(&ProjectMember{From: user.ID(), To: prj.ID()}).Pluck("role", &roles)
+	if len(roles) == 0 {
+		return ProjectRoleNone
+	}
+	return roles[0]
+}
+
+// HasPermission returns true if the role of the user in the project has the permission
+func (prj *Project) HasPermission(user *User, permission ProjectPermission) bool {
+	for _, p := range ProjectPermissions[prj.Role(user)] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// NumericUsersWithPermission returns the IDs of the owner and of the members whose role has the permission
+func (prj *Project) NumericUsersWithPermission(permission ProjectPermission) (users []uint64) {
+	var roles []string
+	for role, permissions := range ProjectPermissions {
+		for _, p := range permissions {
+			if p == permission && role != ProjectRoleOwner {
+				roles = append(roles, role)
+			}
+		}
+	}
+	users = append(users, prj.NumericOwner())
+	if len(roles) > 0 {
+		var members []uint64
+		_ = Db().Model(ProjectMember{}).Where(`"to" = ? AND role IN (?)`, prj.ID(), roles).Pluck(`"from"`, &members)
+		users = append(users, members...)
+	}
+	return
+}
+
+// projectHasPermission returns true if the role of the user in the project identified by id has the permission
+func projectHasPermission(id uint64, user *User, permission ProjectPermission) bool {
+	project, err := NewProject(id)
+	return err == nil && project.ID() == id && project.HasPermission(user, permission)
+}
+
+// checkProjectRank returns an error if the user is not allowed to manage the other user,
+// because the role of the other user is not lower than the role of the user
+func checkProjectRank(project *Project, user, other *User) error {
+	if projectRoleRanks[project.Role(other)] >= projectRoleRanks[project.Role(user)] {
+		return fmt.Errorf("you can't manage %s: the role of %s is not lower than yours", other.Username, other.Username)
+	}
+	return nil
+}
+
+// SetProjectRole changes the role of the other member of the project.
+// The user must have the permission to manage the roles, and can manage only
+// the members with a lower role, granting them at most a lower role
+func (user *User) SetProjectRole(project *Project, other *User, role string) error {
+	if !utils.InSlice(role, []string{ProjectRoleAdmin, ProjectRoleModerator, ProjectRoleMember}) {
+		return errors.New("invalid role: " + role)
+	}
+	if !project.HasPermission(user, ProjectPermissionManageRoles) {
+		return errors.New("you can't change the roles of the members")
+	}
+	if !project.HasMember(other) {
+		return fmt.Errorf("%s is not a member of the project", other.Username)
+	}
+	if err := checkProjectRank(project, user, other); err != nil {
+		return err
+	}
+	if projectRoleRanks[role] >= projectRoleRanks[project.Role(user)] {
+		return fmt.Errorf("you can't grant the %s role", role)
+	}
+	return Db().Exec(`UPDATE groups_members SET role = ? WHERE "from" = ? AND "to" = ?`, role, other.ID(), project.ID())
+}
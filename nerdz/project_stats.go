@@ -0,0 +1,145 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+const (
+	// StatsDay is the interval of the daily statistics buckets
+	StatsDay = "day"
+	// StatsWeek is the interval of the weekly statistics buckets, starting on monday
+	StatsWeek = "week"
+	// StatsMonth is the interval of the monthly statistics buckets
+	StatsMonth = "month"
+
+	// MinStatsBuckets represents the minimum number of statistics buckets that can be required
+	MinStatsBuckets uint64 = 1
+	// MaxStatsBuckets represents the maximum number of statistics buckets that can be required
+	MaxStatsBuckets uint64 = 90
+)
+
+// StatsIntervals are the intervals of the statistics buckets
+var StatsIntervals = []string{StatsDay, StatsWeek, StatsMonth}
+
+// ProjectStatsOptions represent the configuration used to compute the statistics of a project
+type ProjectStatsOptions struct {
+	Interval string    // interval of the buckets, between StatsIntervals
+	N        uint8     // number of buckets, the last one is the current one
+	Until    time.Time // if specified, the last bucket is the one containing this time
+}
+
+// ProjectStatsBucket is the activity of a project in the interval starting at Start
+type ProjectStatsBucket struct {
+	Start        time.Time
+	Posts        uint64
+	Comments     uint64
+	Votes        uint64
+	Posters      uint64 // distinct users that wrote a post
+	NewFollowers uint64
+	Followers    uint64 // followers at the end of the interval, of the ones still following the project
+}
+
+// GetTO returns its Transfer Object
+func (b *ProjectStatsBucket) GetTO() *ProjectStatsBucketTO {
+	return &ProjectStatsBucketTO{
+		original:     b,
+		Start:        b.Start,
+		Timestamp:    b.Start.Unix(),
+		Posts:        b.Posts,
+		Comments:     b.Comments,
+		Votes:        b.Votes,
+		Posters:      b.Posters,
+		NewFollowers: b.NewFollowers,
+		Followers:    b.Followers,
+	}
+}
+
+// ProjectStats are the statistics of the activity of a project, from the oldest bucket
+type ProjectStats struct {
+	Project   uint64
+	Interval  string
+	Members   uint64
+	Followers uint64
+	Buckets   []ProjectStatsBucket
+}
+
+// GetTO returns its Transfer Object
+func (s *ProjectStats) GetTO() *ProjectStatsTO {
+	to := &ProjectStatsTO{
+		original:  s,
+		Project:   s.Project,
+		Interval:  s.Interval,
+		Members:   s.Members,
+		Followers: s.Followers,
+	}
+	for i := range s.Buckets {
+		to.Buckets = append(to.Buckets, s.Buckets[i].GetTO())
+	}
+	return to
+}
+
+// Stats returns the statistics of the activity of the project, aggregated in buckets according to the options
+func (prj *Project) Stats(options ProjectStatsOptions) (*ProjectStats, error) {
+	if options.Interval == "" {
+		options.Interval = StatsDay
+	}
+	if !utils.InSlice(options.Interval, StatsIntervals) {
+		return nil, fmt.Errorf("invalid interval: %s", options.Interval)
+	}
+	if options.Until.IsZero() {
+		options.Until = time.Now().UTC()
+	}
+	n := AtMostStatsBuckets(uint64(options.N))
+
+	// the interval is one of StatsIntervals, thus it can be part of the query
+	interval := `interval '1 ` + options.Interval + `'`
+	query := `WITH buckets AS (
+		SELECT b AS start, b + ` + interval + ` AS stop
+		FROM generate_series(date_trunc('` + options.Interval + `', ?::timestamp) - ?::integer * ` + interval + `,
+			date_trunc('` + options.Interval + `', ?::timestamp), ` + interval + `) b
+	)
+	SELECT start,
+	(SELECT COUNT(*) FROM groups_posts p WHERE p."to" = ? AND p."time" >= start AND p."time" < stop),
+	(SELECT COUNT(*) FROM groups_comments c WHERE c."to" = ? AND c."time" >= start AND c."time" < stop),
+	(SELECT COUNT(*) FROM groups_thumbs v WHERE v."to" = ? AND v."time" >= start AND v."time" < stop),
+	(SELECT COUNT(DISTINCT p."from") FROM groups_posts p WHERE p."to" = ? AND p."time" >= start AND p."time" < stop),
+	(SELECT COUNT(*) FROM groups_followers f WHERE f."to" = ? AND f."time" >= start AND f."time" < stop),
+	(SELECT COUNT(*) FROM groups_followers f WHERE f."to" = ? AND f."time" < stop)
+	FROM buckets ORDER BY start`
+
+	stats := ProjectStats{
+		Project:   prj.ID(),
+		Interval:  options.Interval,
+		Members:   uint64(len(prj.NumericMembers())),
+		Followers: uint64(len(prj.NumericFollowers())),
+	}
+	if err := Db().Raw(query, options.Until, n-1, options.Until,
+		prj.ID(), prj.ID(), prj.ID(), prj.ID(), prj.ID(), prj.ID()).Scan(&stats.Buckets); err != nil {
+		return nil, err
+	}
+	if len(stats.Buckets) == 0 {
+		return nil, errors.New("unable to compute the statistics of the project")
+	}
+	return &stats, nil
+}
@@ -61,3 +61,287 @@ func TestProjectPostlist(t *testing.T) {
 
 	t.Logf("%+v\n", postList)
 }
+
+func TestSearchProjects(t *testing.T) {
+	user, _ := nerdz.NewUser(1)
+	projects := user.SearchProjects(nerdz.DirectoryOptions{Query: prj.Name, Exact: true})
+	if prj.Visible && (len(projects) != 1 || projects[0].ID() != prj.ID()) {
+		t.Fatalf("Expected project(%d) by exact name, got %v", prj.ID(), projects)
+	}
+	for _, project := range user.SearchProjects(nerdz.DirectoryOptions{Query: prj.Name}) {
+		if !user.CanSee(project) {
+			t.Fatalf("The project(%d) shouldn't be visible", project.ID())
+		}
+	}
+}
+
+func TestProjectLifecycle(t *testing.T) {
+	if err := me.CreateProject(&nerdz.Project{Name: prj.Name, Description: "duplicated"}); err == nil {
+		t.Errorf("Creating a project with an existing name should fail")
+	}
+	if err := me.CreateProject(&nerdz.Project{Name: "invalid name", Description: "spaces"}); err == nil {
+		t.Errorf("Creating a project with an invalid name should fail")
+	}
+
+	project := nerdz.Project{Name: "lifecycle.test", Description: "A test project", Open: true}
+	if err := me.CreateProject(&project); err != nil {
+		t.Fatalf("CreateProject should work, but got: %v", err)
+	}
+	if project.NumericOwner() != me.Counter {
+		t.Errorf("The owner should be User(%d), but got: %d", me.Counter, project.NumericOwner())
+	}
+	if created, _ := nerdz.NewProject(project.Counter); created.Visible || !created.Open {
+		t.Errorf("The flags of the project have not been stored: %+v", created)
+	}
+
+	update := project
+	update.Goal = "Test the lifecycle"
+	update.Website.String = "ftp://invalid"
+	if err := me.UpdateProject(&project, &update); err == nil {
+		t.Errorf("Updating the project with an invalid website should fail")
+	}
+	update.Website.String = "https://www.nerdz.eu"
+	update.Visible = true
+	if err := other.UpdateProject(&project, &update); err == nil {
+		t.Errorf("Only the owner should be able to update the project")
+	}
+	if err := me.UpdateProject(&project, &update); err != nil {
+		t.Fatalf("UpdateProject should work, but got: %v", err)
+	}
+	if updated, _ := nerdz.NewProject(project.Counter); updated.Goal != update.Goal || !updated.Visible || updated.Website.String != update.Website.String {
+		t.Errorf("The project has not been updated: %+v", updated)
+	}
+
+	if err := other.TransferProject(&project, other); err == nil {
+		t.Errorf("Only the owner should be able to transfer the project")
+	}
+	if err := me.TransferProject(&project, other); err != nil {
+		t.Fatalf("TransferProject should work, but got: %v", err)
+	}
+	if project.NumericOwner() != other.Counter {
+		t.Errorf("The owner should be User(%d), but got: %d", other.Counter, project.NumericOwner())
+	}
+
+	if err := me.DeleteProject(&project); err == nil {
+		t.Errorf("Only the owner should be able to delete the project")
+	}
+	if err := other.DeleteProject(&project); err != nil {
+		t.Fatalf("DeleteProject should work, but got: %v", err)
+	}
+	if _, err := nerdz.NewProjectByName(project.Name); err == nil {
+		t.Errorf("The project should have been deleted")
+	}
+}
+
+func TestProjectMembership(t *testing.T) {
+	project := nerdz.Project{Name: "membership.test", Description: "A closed test project"}
+	if err := me.CreateProject(&project); err != nil {
+		t.Fatalf("CreateProject should work, but got: %v", err)
+	}
+	defer func() { _ = me.DeleteProject(&project) }()
+
+	// closed project: join request, approved by the owner
+	if err := other.JoinProject(&project); err != nil {
+		t.Fatalf("JoinProject should send a join request, but got: %v", err)
+	}
+	if project.HasMember(other) {
+		t.Fatalf("User(%d) should not be a member of the closed project before the approval", other.Counter)
+	}
+	if requests := project.MembershipRequests(nerdz.ProjectJoinRequest); len(requests) != 1 || requests[0].From != other.Counter {
+		t.Fatalf("Expected the join request of User(%d), but got: %+v", other.Counter, requests)
+	}
+	if err := other.ApproveProjectRequest(&project, other); err == nil {
+		t.Errorf("Only the owner should be able to approve the join requests")
+	}
+	if err := me.ApproveProjectRequest(&project, other); err != nil {
+		t.Fatalf("ApproveProjectRequest should work, but got: %v", err)
+	}
+	if !project.HasMember(other) || len(project.MembershipRequests(nerdz.ProjectJoinRequest)) != 0 {
+		t.Fatalf("User(%d) should be a member without pending join requests", other.Counter)
+	}
+
+	// leave and invitation, accepted joining
+	if err := other.LeaveProject(&project); err != nil {
+		t.Fatalf("LeaveProject should work, but got: %v", err)
+	}
+	if err := me.LeaveProject(&project); err == nil {
+		t.Errorf("The owner should not be able to leave the project")
+	}
+	if err := me.InviteToProject(&project, other); err != nil {
+		t.Fatalf("InviteToProject should work, but got: %v", err)
+	}
+	if invites := project.MembershipRequests(nerdz.ProjectInvite); len(invites) != 1 || invites[0].Sender != me.Counter {
+		t.Fatalf("Expected the invitation of User(%d) sent by User(%d), but got: %+v", other.Counter, me.Counter, invites)
+	}
+	if err := other.JoinProject(&project); err != nil || !project.HasMember(other) {
+		t.Fatalf("Joining after the invitation should make User(%d) a member, but got: %v", other.Counter, err)
+	}
+
+	// kick, deny and open project
+	if err := me.KickFromProject(&project, other); err != nil || project.HasMember(other) {
+		t.Fatalf("KickFromProject should remove User(%d), but got: %v", other.Counter, err)
+	}
+	if err := other.JoinProject(&project); err != nil {
+		t.Fatalf("JoinProject should send a join request, but got: %v", err)
+	}
+	if err := me.DenyProjectRequest(&project, other); err != nil || len(project.MembershipRequests(nerdz.ProjectJoinRequest)) != 0 {
+		t.Fatalf("DenyProjectRequest should delete the join request, but got: %v", err)
+	}
+
+	update := project
+	update.Open = true
+	if err := me.UpdateProject(&project, &update); err != nil {
+		t.Fatalf("UpdateProject should work, but got: %v", err)
+	}
+	if err := other.JoinProject(&project); err != nil || !project.HasMember(other) {
+		t.Fatalf("Joining the open project should make User(%d) a member, but got: %v", other.Counter, err)
+	}
+}
+
+func TestProjectInviteRequester(t *testing.T) {
+	project := nerdz.Project{Name: "requester.test", Description: "A closed test project"}
+	if err := me.CreateProject(&project); err != nil {
+		t.Fatalf("CreateProject should work, but got: %v", err)
+	}
+	defer func() { _ = me.DeleteProject(&project) }()
+
+	if err := me.InviteToProject(&project, other); err != nil {
+		t.Fatalf("InviteToProject should work, but got: %v", err)
+	}
+	if err := other.JoinProject(&project); err != nil {
+		t.Fatalf("Joining after the invitation should work, but got: %v", err)
+	}
+	if err := me.SetProjectRole(&project, other, nerdz.ProjectRoleModerator); err != nil {
+		t.Fatalf("SetProjectRole should work, but got: %v", err)
+	}
+
+	if err := withClosedProfile.JoinProject(&project); err != nil {
+		t.Fatalf("JoinProject should send a join request, but got: %v", err)
+	}
+	if err := other.InviteToProject(&project, withClosedProfile); err == nil || project.HasMember(withClosedProfile) {
+		t.Fatalf("A moderator should not be able to admit User(%d) by inviting after the join request", withClosedProfile.Counter)
+	}
+	if err := me.InviteToProject(&project, withClosedProfile); err != nil || !project.HasMember(withClosedProfile) {
+		t.Fatalf("The owner invitation should approve the join request of User(%d), but got: %v", withClosedProfile.Counter, err)
+	}
+}
+
+func TestProjectRoles(t *testing.T) {
+	project := nerdz.Project{Name: "roles.test", Description: "A test project", Open: true}
+	if err := me.CreateProject(&project); err != nil {
+		t.Fatalf("CreateProject should work, but got: %v", err)
+	}
+	defer func() { _ = me.DeleteProject(&project) }()
+
+	if role := project.Role(me); role != nerdz.ProjectRoleOwner {
+		t.Errorf("Expected the owner role, but got: %s", role)
+	}
+	if role := project.Role(other); role != nerdz.ProjectRoleNone {
+		t.Errorf("Expected no role, but got: %s", role)
+	}
+	if err := other.JoinProject(&project); err != nil || project.Role(other) != nerdz.ProjectRoleMember {
+		t.Fatalf("Joining should give User(%d) the member role, but got: %v", other.Counter, err)
+	}
+	if project.HasPermission(other, nerdz.ProjectPermissionInvite) || project.HasPermission(other, nerdz.ProjectPermissionModerate) {
+		t.Errorf("A member should not be able to invite nor to moderate")
+	}
+	if err := other.SetProjectRole(&project, other, nerdz.ProjectRoleAdmin); err == nil {
+		t.Errorf("A member should not be able to change the roles")
+	}
+	if err := me.SetProjectRole(&project, other, "owner"); err == nil {
+		t.Errorf("The owner role should not be granted")
+	}
+
+	if err := me.SetProjectRole(&project, other, nerdz.ProjectRoleModerator); err != nil {
+		t.Fatalf("SetProjectRole should work, but got: %v", err)
+	}
+	if !project.HasPermission(other, nerdz.ProjectPermissionModerate) || project.HasPermission(other, nerdz.ProjectPermissionEdit) {
+		t.Errorf("A moderator should be able to moderate, but not to edit")
+	}
+	if members := project.Memberships(); len(members) != 1 || members[0].Role != nerdz.ProjectRoleModerator {
+		t.Errorf("Expected the membership of the moderator, but got: %+v", members)
+	}
+
+	post := nerdz.ProjectPost{}
+	post.From = me.Counter
+	post.To = project.ID()
+	post.Message = "Role test post"
+	if err := me.Add(&post); err != nil {
+		t.Fatalf("Add should work, but got: %v", err)
+	}
+	if !other.CanDelete(&post) || other.CanEdit(&post) {
+		t.Errorf("A moderator should be able to delete, but not to edit, the posts of the others")
+	}
+
+	if err := me.SetProjectRole(&project, other, nerdz.ProjectRoleAdmin); err != nil {
+		t.Fatalf("SetProjectRole should work, but got: %v", err)
+	}
+	if !other.CanEdit(&post) {
+		t.Errorf("An admin should be able to edit the posts of the others")
+	}
+	if err := other.SetProjectRole(&project, other, nerdz.ProjectRoleMember); err == nil {
+		t.Errorf("An admin should not be able to change its own role")
+	}
+	if err := other.KickFromProject(&project, me); err == nil {
+		t.Errorf("An admin should not be able to remove the owner")
+	}
+}
+
+func TestProjectStats(t *testing.T) {
+	if _, err := prj.Stats(nerdz.ProjectStatsOptions{Interval: "year"}); err == nil {
+		t.Errorf("Stats with an invalid interval should fail")
+	}
+
+	for _, interval := range nerdz.StatsIntervals {
+		stats, err := prj.Stats(nerdz.ProjectStatsOptions{Interval: interval, N: 4})
+		if err != nil {
+			t.Fatalf("Stats should work, but got: %v", err)
+		}
+		if len(stats.Buckets) != 4 {
+			t.Fatalf("Expected 4 %s buckets, but got %d", interval, len(stats.Buckets))
+		}
+		for i, bucket := range stats.Buckets {
+			if i > 0 && !bucket.Start.After(stats.Buckets[i-1].Start) {
+				t.Errorf("The buckets should be ordered from the oldest: %+v", stats.Buckets)
+			}
+			if bucket.Posters > bucket.Posts || bucket.NewFollowers > bucket.Followers {
+				t.Errorf("Inconsistent bucket: %+v", bucket)
+			}
+		}
+		if last := stats.Buckets[len(stats.Buckets)-1]; last.Followers != stats.Followers {
+			t.Errorf("The followers of the last bucket should be %d, but got %d", stats.Followers, last.Followers)
+		}
+	}
+}
+
+func TestProjectExport(t *testing.T) {
+	var followers []uint64
+	err := prj.ExportFollowers(nil, func(user *nerdz.ProjectExportUser) error {
+		if user.Contacts != nil {
+			t.Errorf("The contact info should not be exported")
+		}
+		followers = append(followers, user.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportFollowers should work, but got: %v", err)
+	}
+	if len(followers) != len(prj.NumericFollowers()) {
+		t.Errorf("Expected %d followers, but got %d", len(prj.NumericFollowers()), len(followers))
+	}
+
+	var members int
+	err = prj.ExportMembers(prj.Owner(), func(user *nerdz.ProjectExportUser) error {
+		if user.Contacts == nil {
+			t.Errorf("The contact info should be exported")
+		}
+		members++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportMembers should work, but got: %v", err)
+	}
+	if members != len(prj.NumericMembers()) {
+		t.Errorf("Expected %d members, but got %d", len(prj.NumericMembers()), members)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// SearchUserPosts is the type of the search results that are user posts
+	SearchUserPosts = "user_posts"
+	// SearchProjectPosts is the type of the search results that are project posts
+	SearchProjectPosts = "project_posts"
+	// SearchUserComments is the type of the search results that are user post comments
+	SearchUserComments = "user_comments"
+	// SearchProjectComments is the type of the search results that are project post comments
+	SearchProjectComments = "project_comments"
+	// SearchPms is the type of the search results that are pms
+	SearchPms = "pms"
+
+	// MinSearchResults represents the minimum number of search results that can be required
+	MinSearchResults uint64 = 1
+	// MaxSearchResults represents the maximum number of search results that can be required
+	MaxSearchResults uint64 = 50
+
+	// searchConfig is the text search configuration of the full-text search.
+	// The messages are in many languages, thus the words are not stemmed
+	searchConfig = "simple"
+)
+
+// SearchTypes are the types of the search results
+var SearchTypes = []string{SearchUserPosts, SearchProjectPosts, SearchUserComments, SearchProjectComments, SearchPms}
+
+// SearchOptions represent the configuration used to search the messages
+type SearchOptions struct {
+	Query    string    // web search syntax: "quoted phrases", OR and -excluded words are supported
+	Types    []string  // types of the messages to search, between SearchTypes
+	Language string    // if specified, search only the messages in this language
+	Since    time.Time // if specified, search only the messages created since this time
+	Until    time.Time // if specified, search only the messages created before this time
+	Author   uint64    // if specified, search only the messages sent by this user
+	Board    uint64    // if specified, search only the messages on this board (the other user, for the pms)
+	N        uint8     // number of results to return
+}
+
+// SearchResult is a message matching the search query. Snippet is the part of the message
+// matching the query, with the matching words within <mark></mark>
+type SearchResult struct {
+	Type    string
+	ID      uint64
+	Snippet string
+	Rank    float64
+	Time    time.Time
+}
+
+// GetTO returns its Transfer Object
+func (r *SearchResult) GetTO(users ...*User) *SearchResultTO {
+	if len(users) != 1 {
+		panic("SearchResult.GetTO requires a user parameter")
+	}
+	user := users[0]
+
+	to := &SearchResultTO{
+		original:  r,
+		Type:      r.Type,
+		Snippet:   r.Snippet,
+		Rank:      r.Rank,
+		Time:      r.Time,
+		Timestamp: r.Time.Unix(),
+	}
+	switch r.Type {
+	case SearchUserPosts:
+		if post, err := NewUserPost(r.ID); err == nil {
+			to.Post = post.GetTO(user)
+		}
+	case SearchProjectPosts:
+		if post, err := NewProjectPost(r.ID); err == nil {
+			to.Post = post.GetTO(user)
+		}
+	case SearchUserComments:
+		if comment, err := NewUserPostComment(r.ID); err == nil {
+			to.UserComment = comment.GetTO(user)
+		}
+	case SearchProjectComments:
+		if comment, err := NewProjectPostComment(r.ID); err == nil {
+			to.ProjectComment = comment.GetTO(user)
+		}
+	case SearchPms:
+		if pm, err := NewPm(r.ID); err == nil {
+			to.Pm = pm.GetTO(user)
+		}
+	}
+	return to
+}
+
+// searchSource describes where and how to search the messages of a type
+type searchSource struct {
+	table string
+	id    string
+	// visibility is the condition on the message "t" that the user must satisfy to see it,
+	// every placeholder is the user ID
+	visibility string
+	// board is the condition on the message "t" to be on the board, every placeholder is the board ID
+	board string
+}
+
+const (
+	// userBoardVisibility hides the messages of the blacklisted users, the messages on the boards
+	// of the users that blacklisted the user and the messages on the private boards
+	userBoardVisibility = `t."from" NOT IN (SELECT "to" FROM blacklist WHERE "from" = ?)
+	AND t."to" NOT IN (SELECT "to" FROM blacklist WHERE "from" = ?)
+	AND t."to" NOT IN (SELECT "from" FROM blacklist WHERE "to" = ?)
+	AND ` + privateBoardVisibility
+	// projectBoardVisibility hides the messages of the blacklisted users
+	// and the messages on the closed projects of which the user is neither a member nor the owner
+	projectBoardVisibility = `t."from" NOT IN (SELECT "to" FROM blacklist WHERE "from" = ?)
+	AND (
+		TRUE IN (SELECT visible FROM groups g WHERE g.counter = t."to")
+		OR
+		? IN (
+			SELECT "from" FROM groups_members gm WHERE gm."to" = t."to"
+			UNION ALL
+			SELECT "from" FROM groups_owners go WHERE go."to" = t."to")
+	)`
+)
+
+var searchSources = map[string]searchSource{
+	SearchUserPosts:       {table: "posts", id: "hpid", visibility: userBoardVisibility, board: `t."to" = ?`},
+	SearchProjectPosts:    {table: "groups_posts", id: "hpid", visibility: projectBoardVisibility, board: `t."to" = ?`},
+	SearchUserComments:    {table: "comments", id: "hcid", visibility: userBoardVisibility, board: `t."to" = ?`},
+	SearchProjectComments: {table: "groups_comments", id: "hcid", visibility: projectBoardVisibility, board: `t."to" = ?`},
+	SearchPms:             {table: "pms", id: "pmid", visibility: `(t."from" = ? OR t."to" = ?)`, board: `(t."from" = ? OR t."to" = ?)`},
+}
+
+// Search returns the messages, visible by the user, matching the options.
+// The results are ordered from the most recent: to get the next results,
+// search again with Until set to the time of the last result
+func (user *User) Search(options SearchOptions) (*[]SearchResult, error) {
+	options.Query = strings.TrimSpace(options.Query)
+	if options.Query == "" {
+		return nil, errors.New("the search query must be not empty")
+	}
+	if len(options.Types) == 0 {
+		options.Types = SearchTypes
+	}
+	n := int(AtMostSearchResults(uint64(options.N)))
+
+	var results []SearchResult
+	for _, searchType := range options.Types {
+		source, ok := searchSources[searchType]
+		if !ok {
+			return nil, fmt.Errorf("invalid search type: %s", searchType)
+		}
+
+		vector := "to_tsvector('" + searchConfig + "', t.message)"
+		query := `SELECT '` + searchType + `', t.` + source.id + `,
+		ts_headline('` + searchConfig + `', t.message, q, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2'),
+		ts_rank(` + vector + `, q), t."time"
+		FROM ` + source.table + ` t, websearch_to_tsquery('` + searchConfig + `', ?) q
+		WHERE ` + vector + ` @@ q AND ` + source.visibility
+		args := []interface{}{options.Query}
+		for i := strings.Count(source.visibility, "?"); i > 0; i-- {
+			args = append(args, user.ID())
+		}
+
+		if options.Language != "" {
+			query += ` AND t.lang = ?`
+			args = append(args, options.Language)
+		}
+		if !options.Since.IsZero() {
+			query += ` AND t."time" >= ?`
+			args = append(args, options.Since)
+		}
+		if !options.Until.IsZero() {
+			query += ` AND t."time" < ?`
+			args = append(args, options.Until)
+		}
+		if options.Author != 0 {
+			query += ` AND t."from" = ?`
+			args = append(args, options.Author)
+		}
+		if options.Board != 0 {
+			query += ` AND ` + source.board
+			for i := strings.Count(source.board, "?"); i > 0; i-- {
+				args = append(args, options.Board)
+			}
+		}
+		query += fmt.Sprintf(` ORDER BY t."time" DESC LIMIT %d`, n)
+
+		var found []SearchResult
+		if err := Db().Raw(query, args...).Scan(&found); err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	// every type has at most n results: merge them keeping the n most recent
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Time.After(results[j].Time)
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+	return &results, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// Reasons of the subscription of a user to the comments of a post.
+// The comment notifications are sent to the subscribed users that didn't mute the post
+const (
+	// SubscriptionAuthor is the reason of the subscription of the user that wrote the post, or owns its user board
+	SubscriptionAuthor = "author"
+	// SubscriptionCommenter is the reason of the subscription of the users that commented the post
+	SubscriptionCommenter = "commenter"
+	// SubscriptionLurker is the reason of the subscription of the users that lurk the post
+	SubscriptionLurker = "lurker"
+)
+
+// PostSubscription is the subscription of a user to the comments of a post.
+// The user is subscribed when there is a reason and the post isn't muted
+type PostSubscription struct {
+	Hpid   uint64
+	Type   boardType
+	Reason string // between SubscriptionAuthor, SubscriptionCommenter and SubscriptionLurker, empty if there is no reason
+	Muted  bool   // true when the user locked the notifications of the post
+}
+
+// Subscribed returns true if the user receives the comment notifications of the post
+func (s *PostSubscription) Subscribed() bool {
+	return s.Reason != "" && !s.Muted
+}
+
+// GetTO returns its Transfer Object
+func (s *PostSubscription) GetTO(users ...*User) *PostSubscriptionTO {
+	return &PostSubscriptionTO{
+		original:   s,
+		Hpid:       s.Hpid,
+		Type:       s.Type,
+		Reason:     s.Reason,
+		Muted:      s.Muted,
+		Subscribed: s.Subscribed(),
+	}
+}
+
+// Subscription returns the subscription of the user to the comments of the post
+func (user *User) Subscription(post ExistingPost) (*PostSubscription, error) {
+	if post == nil {
+		return nil, errors.New("unable to get the subscription to undefined post")
+	}
+
+	var comments, locks uint8
+	subscription := PostSubscription{Hpid: post.ID()}
+	switch post := post.(type) {
+	case *UserPost:
+		subscription.Type = UserBoardID
+		if post.From == user.ID() || post.To == user.ID() {
+			subscription.Reason = SubscriptionAuthor
+		}
+		_ = Db().Model(UserPostComment{}).Where(&UserPostComment{Hpid: post.ID(), From: user.ID()}).Count(&comments)
+		_ = Db().Model(UserPostLock{}).Where(&UserPostLock{Hpid: post.ID(), User: user.ID()}).Count(&locks)
+
+	case *ProjectPost:
+		subscription.Type = ProjectBoardID
+		if post.From == user.ID() {
+			subscription.Reason = SubscriptionAuthor
+		}
+		_ = Db().Model(ProjectPostComment{}).Where(&ProjectPostComment{Hpid: post.ID(), From: user.ID()}).Count(&comments)
+		_ = Db().Model(ProjectPostLock{}).Where(&ProjectPostLock{Hpid: post.ID(), User: user.ID()}).Count(&locks)
+
+	default:
+		return nil, errors.New("invalid post type " + reflect.TypeOf(post).String())
+	}
+
+	if subscription.Reason == "" && comments > 0 {
+		subscription.Reason = SubscriptionCommenter
+	}
+	if subscription.Reason == "" && utils.InSlice(user.ID(), post.NumericLurkers()) {
+		subscription.Reason = SubscriptionLurker
+	}
+	subscription.Muted = locks > 0
+	return &subscription, nil
+}
+
+// Subscribe subscribes the user to the comments of the post: the post is unmuted and,
+// if the user has no reason to be subscribed, the user lurks the post
+func (user *User) Subscribe(post ExistingPost) (*PostSubscription, error) {
+	subscription, err := user.Subscription(post)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Muted {
+		if err = user.Unlock(post); err != nil {
+			return nil, err
+		}
+		subscription.Muted = false
+	}
+	if subscription.Reason == "" {
+		if _, err = user.Lurk(post); err != nil {
+			return nil, err
+		}
+		subscription.Reason = SubscriptionLurker
+	}
+	return subscription, nil
+}
+
+// Unsubscribe mutes the comment notifications of the post, without removing the reason
+// of the subscription: the lurkers keep lurking the post
+func (user *User) Unsubscribe(post ExistingPost) (*PostSubscription, error) {
+	subscription, err := user.Subscription(post)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.Muted {
+		if _, err = user.Lock(post); err != nil {
+			return nil, err
+		}
+		subscription.Muted = true
+	}
+	return subscription, nil
+}
+
+// Subscriptions returns a slice of Message representing the posts whose comment notifications
+// are received by the user, still visible by the user. Posts are filtered by specified options.
+func (user *User) Subscriptions(options PostlistOptions) *[]Message {
+	posts, err := user.messagelist(`CASE type
+		WHEN 1 THEN (
+			"from" = ? OR "to" = ?
+			OR hpid IN (SELECT hpid FROM `+UserPostComment{}.TableName()+` WHERE "from" = ?)
+			OR hpid IN (SELECT hpid FROM `+UserPostLurk{}.TableName()+` WHERE "from" = ?)
+		) AND hpid NOT IN (SELECT hpid FROM `+UserPostLock{}.TableName()+` WHERE "user" = ?)
+		ELSE (
+			"from" = ?
+			OR hpid IN (SELECT hpid FROM `+ProjectPostComment{}.TableName()+` WHERE "from" = ?)
+			OR hpid IN (SELECT hpid FROM `+ProjectPostLurk{}.TableName()+` WHERE "from" = ?)
+		) AND hpid NOT IN (SELECT hpid FROM `+ProjectPostLock{}.TableName()+` WHERE "user" = ?)
+		END`, []interface{}{user.ID(), user.ID(), user.ID(), user.ID(), user.ID(), user.ID(), user.ID(), user.ID(), user.ID()}, options)
+	if err != nil {
+		log.Errorf("(Subscriptions) Error in query.Scan: %s", err)
+	}
+	return posts
+}
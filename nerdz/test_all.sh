@@ -18,6 +18,11 @@ cd "$TEST_DB_PATH"
 ./initdb.sh "$ROLE" "$DB_NAME" "$DB_PASS"
 cd "$LOCAL_PATH"
 
+# Apply the schema changes not yet part of nerdz-test-db
+for migration in migrations/*.sql; do
+    psql -U "$ROLE" -d "$DB_NAME" -f "$migration"
+done
+
 echo 'Test database created'; echo
 echo 'Begin tests...'; echo
 
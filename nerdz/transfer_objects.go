@@ -165,8 +165,10 @@ type ProjectNotifyTO struct {
 	ToInfo    *InfoTO   `json:"to"`
 	Time      time.Time `json:"time"`
 	Timestamp int64     `json:"timestamp"`
-	Hpid      uint64    `json:"hpid"`
+	Hpid      uint64    `json:"hpid,omitempty"`
 	Counter   uint64    `json:"counter"`
+	Reason    string    `json:"reason"`
+	UserInfo  *InfoTO   `json:"user,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -277,6 +279,7 @@ type ProfileTO struct {
 	Push           bool      `json:"push"`        // ?API?
 	Pushregtime    time.Time `json:"pushregtime"` // ?API?
 	Closed         bool      `json:"closed"`
+	WhitelistOnly  bool      `json:"whitelistOnly"`
 }
 
 // Original returns the original object of the TO
@@ -297,6 +300,8 @@ type PostTO struct {
 	Lang           string    `json:"lang"`
 	News           bool      `json:"news"`
 	Closed         bool      `json:"closed"`
+	ClosedBy       *InfoTO   `json:"closedBy"` // nil when the post is open, or when who closed it is unknown
+	Pinned         bool      `json:"pinned"`
 	FromInfo       *InfoTO   `json:"from"`
 	ToInfo         *InfoTO   `json:"to"`
 	Rate           int       `json:"rate"`
@@ -312,6 +317,8 @@ type PostTO struct {
 	CanLurk        bool      `json:"canLurk"`
 	CanEdit        bool      `json:"canEdit"`
 	CanDelete      bool      `json:"canDelete"`
+	CanPin         bool      `json:"canPin"`
+	CanClose       bool      `json:"canClose"`
 }
 
 // Original returns the original object of the TO
@@ -330,6 +337,8 @@ type UserPostRevisionTO struct {
 	Timestamp int64     `json:"timestamp"`
 	RevNo     uint16    `json:"revNo"`
 	Counter   uint64    `json:"counter"`
+	// Diff is the unified diff against the previous revision, when required
+	Diff string `json:"diff,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -407,6 +416,8 @@ type UserPostCommentRevisionTO struct {
 	Timestamp int64     `json:"timestamp"`
 	RevNo     int8      `json:"revNo"`
 	Counter   uint64    `json:"counter"`
+	// Diff is the unified diff against the previous revision, when required
+	Diff string `json:"diff,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -436,12 +447,13 @@ func (to *UserPostBookmarkTO) Original() *UserPostBookmark {
 // swagger:model
 type ConversationTO struct {
 	original    *Conversation
-	FromInfo    *InfoTO   `json:"from"`
-	ToInfo      *InfoTO   `json:"to"`
-	LastMessage string    `json:"lastMessage"`
-	Time        time.Time `json:"time"`
-	Timestamp   int64     `json:"timestamp"`
-	ToRead      bool      `json:"toRead"`
+	FromInfo    *InfoTO    `json:"from"`
+	ToInfo      *InfoTO    `json:"to"`
+	Group       *PmGroupTO `json:"group,omitempty"`
+	LastMessage string     `json:"lastMessage"`
+	Time        time.Time  `json:"time"`
+	Timestamp   int64      `json:"timestamp"`
+	ToRead      bool       `json:"toRead"`
 }
 
 // Original returns the original object of the TO
@@ -449,25 +461,112 @@ func (to *ConversationTO) Original() *Conversation {
 	return to.original
 }
 
+// UnreadConversationTO represents the TO of UnreadConversation
+//
+// swagger:model
+type UnreadConversationTO struct {
+	original *UnreadConversation
+	FromInfo *InfoTO `json:"from"`
+	Count    uint64  `json:"count"`
+}
+
+// Original returns the original object of the TO
+func (to *UnreadConversationTO) Original() *UnreadConversation {
+	return to.original
+}
+
 // PmTO represents the TO of Pm
 //
 // swagger:model
 type PmTO struct {
-	original  *Pm
+	original       *Pm
+	Pmid           uint64    `json:"pmid"`
+	FromInfo       *InfoTO   `json:"from"`
+	ToInfo         *InfoTO   `json:"to"`
+	Message        string    `json:"message"`
+	Lang           string    `json:"lang"`
+	ToRead         bool      `json:"toRead"`
+	Time           time.Time `json:"time"`
+	Timestamp      int64     `json:"timestamp"`
+	CanEdit        bool      `json:"canEdit"`
+	CanDelete      bool      `json:"canDelete"`
+	RevisionsCount uint8     `json:"revisions"`
+}
+
+// Original returns the original object of the TO
+func (to *PmTO) Original() *Pm {
+	return to.original
+}
+
+// PmRevisionTO represents the TO of PmRevision
+//
+// swagger:model
+type PmRevisionTO struct {
+	original  *PmRevision
 	Pmid      uint64    `json:"pmid"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+	Timestamp int64     `json:"timestamp"`
+	RevNo     uint16    `json:"revNo"`
+	Counter   uint64    `json:"counter"`
+	// Diff is the unified diff against the previous revision, when required
+	Diff string `json:"diff,omitempty"`
+}
+
+// Original returns the original object of the TO
+func (to *PmRevisionTO) Original() *PmRevision {
+	return to.original
+}
+
+// PmGroupTO represents the TO of PmGroup
+//
+// swagger:model
+type PmGroupTO struct {
+	original  *PmGroup
+	ID        uint64             `json:"id"`
+	Name      string             `json:"name"`
+	Members   []*PmGroupMemberTO `json:"members"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Timestamp int64              `json:"timestamp"`
+}
+
+// Original returns the original object of the TO
+func (to *PmGroupTO) Original() *PmGroup {
+	return to.original
+}
+
+// PmGroupMemberTO represents the TO of PmGroupMember
+//
+// swagger:model
+type PmGroupMemberTO struct {
+	original  *PmGroupMember
+	UserInfo  *InfoTO   `json:"user"`
+	Admin     bool      `json:"admin"`
+	JoinedAt  time.Time `json:"joinedAt"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+// Original returns the original object of the TO
+func (to *PmGroupMemberTO) Original() *PmGroupMember {
+	return to.original
+}
+
+// PmGroupMessageTO represents the TO of PmGroupMessage
+//
+// swagger:model
+type PmGroupMessageTO struct {
+	original  *PmGroupMessage
+	Pmid      uint64    `json:"pmid"`
+	GroupID   uint64    `json:"groupId"`
 	FromInfo  *InfoTO   `json:"from"`
-	ToInfo    *InfoTO   `json:"to"`
 	Message   string    `json:"message"`
 	Lang      string    `json:"lang"`
-	ToRead    bool      `json:"toRead"`
 	Time      time.Time `json:"time"`
 	Timestamp int64     `json:"timestamp"`
-	CanEdit   bool      `json:"canEdit"`
-	CanDelete bool      `json:"canDelete"`
 }
 
 // Original returns the original object of the TO
-func (to *PmTO) Original() *Pm {
+func (to *PmGroupMessageTO) Original() *PmGroupMessage {
 	return to.original
 }
 
@@ -504,6 +603,7 @@ type ProjectMemberTO struct {
 	Timestamp int64     `json:"timestamp"`
 	ToNotify  bool      `json:"toNotify"`
 	Counter   uint64    `json:"counter"`
+	Role      string    `json:"role"`
 }
 
 // Original returns the original object of the TO
@@ -511,6 +611,25 @@ func (to *ProjectMemberTO) Original() *ProjectMember {
 	return to.original
 }
 
+// ProjectMembershipRequestTO represents the TO of ProjectMembershipRequest
+//
+// swagger:model
+type ProjectMembershipRequestTO struct {
+	original   *ProjectMembershipRequest
+	FromInfo   *InfoTO   `json:"from"`
+	ToInfo     *InfoTO   `json:"to"`
+	Kind       string    `json:"kind"`
+	SenderInfo *InfoTO   `json:"sender"`
+	Time       time.Time `json:"time"`
+	Timestamp  int64     `json:"timestamp"`
+	Counter    uint64    `json:"counter"`
+}
+
+// Original returns the original object of the TO
+func (to *ProjectMembershipRequestTO) Original() *ProjectMembershipRequest {
+	return to.original
+}
+
 // ProjectOwnerTO represents the TO of ProjectOwner
 //
 // swagger:model
@@ -540,6 +659,8 @@ type ProjectPostRevisionTO struct {
 	Timestamp int64     `json:"timestamp"`
 	RevNo     uint16    `json:"revNo"`
 	Counter   uint64    `json:"counter"`
+	// Diff is the unified diff against the previous revision, when required
+	Diff string `json:"diff,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -617,6 +738,8 @@ type ProjectPostCommentRevisionTO struct {
 	Timestamp int64     `json:"timestamp"`
 	RevNo     uint16    `json:"revNo"`
 	Counter   uint64    `json:"counter"`
+	// Diff is the unified diff against the previous revision, when required
+	Diff string `json:"diff,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -768,6 +891,7 @@ type MentionTO struct {
 	Time      time.Time `json:"time"`
 	Timestamp int64     `json:"timestamp"`
 	ToNotify  bool      `json:"toNotify"`
+	Post      *PostTO   `json:"post,omitempty"`
 }
 
 // Original returns the original object of the TO
@@ -839,3 +963,193 @@ type InfoTO struct {
 func (to *InfoTO) Original() *Info {
 	return to.original
 }
+
+// WebhookTO represents the TO of Webhook
+//
+// swagger:model
+type WebhookTO struct {
+	original  *Webhook
+	ID        uint64    `json:"id"`
+	ClientID  uint64    `json:"clientId"`
+	URL       string    `json:"url"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+// Original returns the original object of the TO
+func (to *WebhookTO) Original() *Webhook {
+	return to.original
+}
+
+// WebhookDeliveryTO represents the TO of WebhookDelivery
+//
+// swagger:model
+type WebhookDeliveryTO struct {
+	original      *WebhookDelivery
+	ID            uint64    `json:"id"`
+	WebhookID     uint64    `json:"webhookId"`
+	Event         string    `json:"event"`
+	Payload       string    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      uint8     `json:"attempts"`
+	StatusCode    int       `json:"statusCode"`
+	LastError     string    `json:"lastError"`
+	CreatedAt     time.Time `json:"createdAt"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	Timestamp     int64     `json:"timestamp"`
+}
+
+// Original returns the original object of the TO
+func (to *WebhookDeliveryTO) Original() *WebhookDelivery {
+	return to.original
+}
+
+// PresenceTO represents the TO of Presence
+//
+// swagger:model
+type PresenceTO struct {
+	original *Presence
+	User     uint64    `json:"user"`
+	Online   bool      `json:"online"`
+	Last     time.Time `json:"last"`
+}
+
+// Original returns the original object of the TO
+func (to *PresenceTO) Original() *Presence {
+	return to.original
+}
+
+// SearchResultTO represents the TO of SearchResult.
+// According to the type, one between Post, UserComment, ProjectComment and Pm is set
+//
+// swagger:model
+type SearchResultTO struct {
+	original       *SearchResult
+	Type           string                `json:"type"`
+	Snippet        string                `json:"snippet"`
+	Rank           float64               `json:"rank"`
+	Time           time.Time             `json:"time"`
+	Timestamp      int64                 `json:"timestamp"`
+	Post           *PostTO               `json:"post,omitempty"`
+	UserComment    *UserPostCommentTO    `json:"userComment,omitempty"`
+	ProjectComment *ProjectPostCommentTO `json:"projectComment,omitempty"`
+	Pm             *PmTO                 `json:"pm,omitempty"`
+}
+
+// Original returns the original object of the TO
+func (to *SearchResultTO) Original() *SearchResult {
+	return to.original
+}
+
+// ProjectStatsBucketTO represents the TO of ProjectStatsBucket
+//
+// swagger:model
+type ProjectStatsBucketTO struct {
+	original     *ProjectStatsBucket
+	Start        time.Time `json:"start"`
+	Timestamp    int64     `json:"timestamp"`
+	Posts        uint64    `json:"posts"`
+	Comments     uint64    `json:"comments"`
+	Votes        uint64    `json:"votes"`
+	Posters      uint64    `json:"posters"`
+	NewFollowers uint64    `json:"newFollowers"`
+	Followers    uint64    `json:"followers"`
+}
+
+// Original returns the original object of the TO
+func (to *ProjectStatsBucketTO) Original() *ProjectStatsBucket {
+	return to.original
+}
+
+// ProjectStatsTO represents the TO of ProjectStats
+//
+// swagger:model
+type ProjectStatsTO struct {
+	original  *ProjectStats
+	Project   uint64                  `json:"project"`
+	Interval  string                  `json:"interval"`
+	Members   uint64                  `json:"members"`
+	Followers uint64                  `json:"followers"`
+	Buckets   []*ProjectStatsBucketTO `json:"buckets"`
+}
+
+// Original returns the original object of the TO
+func (to *ProjectStatsTO) Original() *ProjectStats {
+	return to.original
+}
+
+// BoardSettingsTO represents the TO of BoardSettings
+//
+// swagger:model
+type BoardSettingsTO struct {
+	original      *BoardSettings
+	Closed        bool      `json:"closed"`
+	Private       bool      `json:"private"`
+	WhitelistOnly bool      `json:"whitelistOnly"`
+	Whitelist     []*InfoTO `json:"whitelist"`
+}
+
+// Original returns the original object of the TO
+func (to *BoardSettingsTO) Original() *BoardSettings {
+	return to.original
+}
+
+// PostSubscriptionTO represents the TO of PostSubscription
+//
+// swagger:model
+type PostSubscriptionTO struct {
+	original   *PostSubscription
+	Hpid       uint64    `json:"hpid"`
+	Type       boardType `json:"type"`
+	Reason     string    `json:"reason"`
+	Muted      bool      `json:"muted"`
+	Subscribed bool      `json:"subscribed"`
+}
+
+// Original returns the original object of the TO
+func (to *PostSubscriptionTO) Original() *PostSubscription {
+	return to.original
+}
+
+// ProjectExportUserTO represents the TO of ProjectExportUser
+//
+// swagger:model
+type ProjectExportUserTO struct {
+	original  *ProjectExportUser
+	ID        uint64         `json:"id"`
+	Username  string         `json:"username"`
+	Joined    time.Time      `json:"joined"`
+	Timestamp int64          `json:"timestamp"`
+	Contacts  *ContactInfoTO `json:"contacts,omitempty"`
+}
+
+// Original returns the original object of the TO
+func (to *ProjectExportUserTO) Original() *ProjectExportUser {
+	return to.original
+}
+
+// DraftTO represents the TO of Draft
+//
+// swagger:model
+type DraftTO struct {
+	original         *Draft
+	ID               uint64     `json:"id"`
+	FromInfo         *InfoTO    `json:"from"`
+	ToInfo           *InfoTO    `json:"to"`
+	Type             boardType  `json:"type"`
+	Message          string     `json:"message"`
+	Lang             string     `json:"lang"`
+	Scheduled        bool       `json:"scheduled"`
+	PublishAt        *time.Time `json:"publishAt"`
+	PublishTimestamp int64      `json:"publishTimestamp,omitempty"`
+	Error            string     `json:"error"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	Timestamp        int64      `json:"timestamp"`
+}
+
+// Original returns the original object of the TO
+func (to *DraftTO) Original() *Draft {
+	return to.original
+}
@@ -112,6 +112,7 @@ type BoardInfo struct {
 	Dateformat     string
 	IsClosed       bool
 	Private        bool
+	WhitelistOnly  bool
 	Whitelist      []*User
 	UserScript     *url.URL
 }
@@ -156,3 +157,21 @@ func (i *Info) GetTO() *InfoTO {
 		BoardString: i.BoardString,
 	}
 }
+
+// Presence is the struct that contains the online status of an user
+type Presence struct {
+	User   uint64
+	Online bool
+	// Last is the time of the last activity. It's the zero time when the user hides the online status
+	Last time.Time
+}
+
+// GetTO returns its Transfer Object
+func (p *Presence) GetTO() *PresenceTO {
+	return &PresenceTO{
+		original: p,
+		User:     p.User,
+		Online:   p.Online,
+		Last:     p.Last,
+	}
+}
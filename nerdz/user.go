@@ -24,6 +24,7 @@ import (
 	"net/mail"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -32,11 +33,36 @@ import (
 	"github.com/nerdzeu/nerdz-api/utils"
 )
 
+const (
+	// MinUsers represents the minimum users number that can be required in a list of users
+	MinUsers uint64 = 1
+	// MaxUsers represents the maximum users number that can be required in a list of users
+	MaxUsers uint64 = 50
+)
+
+// UsersOptions represent the configuration used to fetch a list of users
+type UsersOptions struct {
+	N     uint8  // number of users to return
+	Older uint64 // if specified, tells to the function that is using this struct to return N users OLDER (registered before) than the user with the specified "Older" ID
+}
+
 // NewUser returns the user with the specified id
 func NewUser(id uint64) (*User, error) {
 	return NewUserWhere(&User{Counter: id})
 }
 
+// NewUserByUsername returns the user with the specified username (case insensitive)
+func NewUserByUsername(username string) (*User, error) {
+	var counters []uint64
+	if err := Db().Model(User{}).Where("LOWER(username) = LOWER(?)", username).Pluck("counter", &counters); err != nil {
+		return nil, err
+	}
+	if len(counters) == 0 {
+		return nil, errors.New("user " + username + " does not exist")
+	}
+	return NewUser(counters[0])
+}
+
 // NewUserWhere returns the first user that matches the description
 func NewUserWhere(description *User) (user *User, e error) {
 	user = new(User)
@@ -158,7 +184,7 @@ func (user *User) Interests() (interests []string) {
 func (user *User) PersonalInfo() *PersonalInfo {
 	return &PersonalInfo{
 		Username:  user.Username,
-		IsOnline:  user.Viewonline && user.Last.Add(time.Duration(5)*time.Minute).After(time.Now()),
+		IsOnline:  user.Presence().Online,
 		Nation:    user.Lang,
 		Timezone:  user.Timezone,
 		Name:      user.Name,
@@ -172,22 +198,27 @@ func (user *User) PersonalInfo() *PersonalInfo {
 
 // ContactInfo returns a *ContactInfo struct
 func (user *User) ContactInfo() *ContactInfo {
+	return contactInfo(&user.Profile)
+}
+
+// contactInfo returns the contact info stored in the profile
+func contactInfo(profile *Profile) *ContactInfo {
 	// Errors should never occurs, since values are stored in db after have been controlled
-	telegram, _ := url.Parse(user.Profile.Telegram)
-	website, _ := url.Parse(user.Profile.Website)
-	github, _ := url.Parse(user.Profile.Github)
-	facebook, _ := url.Parse(user.Profile.Facebook)
-	twitter, _ := url.Parse(user.Profile.Twitter)
+	telegram, _ := url.Parse(profile.Telegram)
+	website, _ := url.Parse(profile.Website)
+	github, _ := url.Parse(profile.Github)
+	facebook, _ := url.Parse(profile.Facebook)
+	twitter, _ := url.Parse(profile.Twitter)
 
 	return &ContactInfo{
 		Website:  website,
 		GitHub:   github,
-		Skype:    user.Profile.Skype,
-		Jabber:   user.Profile.Jabber,
+		Skype:    profile.Skype,
+		Jabber:   profile.Jabber,
 		Telegram: telegram,
 		Facebook: facebook,
 		Twitter:  twitter,
-		Steam:    user.Profile.Steam}
+		Steam:    profile.Steam}
 }
 
 // BoardInfo returns a *BoardInfo struct
@@ -207,6 +238,7 @@ func (user *User) BoardInfo() *BoardInfo {
 		Dateformat:     user.Profile.Dateformat,
 		IsClosed:       user.Profile.Closed,
 		Private:        user.Private,
+		WhitelistOnly:  user.Profile.WhitelistOnly,
 		Whitelist:      user.Whitelist()}
 }
 
@@ -288,13 +320,55 @@ func (user *User) UserHome(options PostlistOptions) *[]UserPost {
 // Home returns a slice of Post representing the user home. Posts are
 // filtered by specified options.
 func (user *User) Home(options PostlistOptions) *[]Message {
+	posts, err := user.messagelist("", nil, options)
+	if err != nil {
+		log.Errorf("(Home) Error in query.Scan: %s", err)
+	}
+	return posts
+}
+
+// Bookmarks returns a slice of Message representing the posts bookmarked by the user
+// and still visible by the user. Posts are filtered by specified options.
+func (user *User) Bookmarks(options PostlistOptions) *[]Message {
+	posts, err := user.messagelist(`CASE type
+		WHEN 1 THEN hpid IN (SELECT hpid FROM `+UserPostBookmark{}.TableName()+` WHERE "from" = ?)
+		ELSE hpid IN (SELECT hpid FROM `+ProjectPostBookmark{}.TableName()+` WHERE "from" = ?)
+		END`, []interface{}{user.ID(), user.ID()}, options)
+	if err != nil {
+		log.Errorf("(Bookmarks) Error in query.Scan: %s", err)
+	}
+	return posts
+}
+
+// Lurks returns a slice of Message representing the posts lurked by the user
+// and still visible by the user. Posts are filtered by specified options.
+func (user *User) Lurks(options PostlistOptions) *[]Message {
+	posts, err := user.messagelist(`CASE type
+		WHEN 1 THEN hpid IN (SELECT hpid FROM `+UserPostLurk{}.TableName()+` WHERE "from" = ?)
+		ELSE hpid IN (SELECT hpid FROM `+ProjectPostLurk{}.TableName()+` WHERE "from" = ?)
+		END`, []interface{}{user.ID(), user.ID()}, options)
+	if err != nil {
+		log.Errorf("(Lurks) Error in query.Scan: %s", err)
+	}
+	return posts
+}
+
+// messagelist returns the users and projects posts visible by the user, from the most recent.
+// If condition is not empty, only the posts satisfying the condition are returned
+func (user *User) messagelist(condition string, args []interface{}, options PostlistOptions) (*[]Message, error) {
 	var message Message
 	query := Db().
 		CTE(`WITH blist AS (SELECT "to" FROM blacklist WHERE "from" = ?)`, user.ID()). // WITH cte
 		Table(message.TableName()).                                                    // select * from messages
 		Where(`"from" NOT IN (SELECT * FROM blist) AND
 		CASE type
-		WHEN 1 THEN "to" NOT IN (SELECT * FROM blist)
+		WHEN 1 THEN "to" NOT IN (SELECT * FROM blist) AND ( -- private boards conditions
+			"to" = ?
+			OR
+			"to" NOT IN (SELECT counter FROM users WHERE private)
+			OR
+			? IN (SELECT w."to" FROM whitelist w WHERE w."from" = messages."to")
+		)
 		ELSE ( -- groups conditions
 			TRUE IN (SELECT visible FROM groups g WHERE g.counter = "to")
 			OR
@@ -304,16 +378,17 @@ func (user *User) Home(options PostlistOptions) *[]Message {
 				SELECT "from" FROM groups_owners go WHERE go."to" = "to")
 			)
 		)
-		END`, user.ID()).
+		END`, user.ID(), user.ID(), user.ID()).
 		Order("time DESC")
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
 
 	options.Model = message
 	query = postlistQueryBuilder(query, options, user) // handle following, followers, language, newer, older, between...
 	var posts []Message
-	if err := query.Scan(&posts); err != nil {
-		log.Errorf("(Home) Error in query.Scan: %s", err)
-	}
-	return &posts
+	err := query.Scan(&posts)
+	return &posts, err
 }
 
 // Pms returns a slice of Pm, representing the list of the last messages exchanged with other users
@@ -420,7 +495,7 @@ func (user *User) Conversations() (*[]Conversation, error) {
 			SELECT MAX("time") AS times, "to" as otherid, FALSE AS to_read FROM pms WHERE "from" = ? GROUP BY "to", to_read
 		) AS tmp GROUP BY otherid, to_read
 	)
-	SELECT c.me, c.otherid, p.message, MAX(c."time") AS t, c.to_read
+	SELECT c.me, c.otherid, p.message, MAX(c."time") AS t, c.to_read, 0::bigint AS "group"
 	FROM conversations_with_duplicates c
 	INNER JOIN pms p
 	ON c."time" = p."time" AND (
@@ -430,7 +505,74 @@ func (user *User) Conversations() (*[]Conversation, error) {
 	)
 	GROUP BY c.me, c.otherid, p.message, c.to_read
 	ORDER BY to_read DESC, t DESC`, user.ID(), user.ID(), user.ID()).Scan(&convList)
-	return &convList, err
+	if err != nil && err != sql.ErrNoRows {
+		return &convList, err
+	}
+
+	var groupList []Conversation
+	if err = Db().Raw(`SELECT COALESCE(m."from", 0), 0::bigint, COALESCE(m.message, ''), COALESCE(m."time", g.created_at) AS t,
+		EXISTS(
+			SELECT 1 FROM pm_group_messages u WHERE u.group_id = g.id AND u.pmid > gm.last_read AND u."from" <> gm.user_id
+		) AS to_read, g.id
+	FROM pm_group_members gm
+	INNER JOIN pm_groups g ON g.id = gm.group_id
+	LEFT JOIN LATERAL (
+		SELECT "from", message, "time" FROM pm_group_messages WHERE group_id = g.id ORDER BY pmid DESC LIMIT 1
+	) m ON TRUE
+	WHERE gm.user_id = ?`, user.ID()).Scan(&groupList); err != nil && err != sql.ErrNoRows {
+		return &convList, err
+	}
+
+	// group conversations are merged keeping the order: the ones to read first, the most recent first
+	convList = append(convList, groupList...)
+	sort.SliceStable(convList, func(i, j int) bool {
+		if convList[i].ToRead != convList[j].ToRead {
+			return convList[i].ToRead
+		}
+		return convList[i].Time.After(convList[j].Time)
+	})
+	return &convList, nil
+}
+
+// ReadConversation marks as read the pms sent by the other user to the current user
+// and notifies the read receipt to the conversation
+func (user *User) ReadConversation(other uint64) error {
+	if err := Db().Exec(`UPDATE pms SET to_read = FALSE WHERE "from" = ? AND "to" = ? AND to_read`, other, user.ID()); err != nil {
+		return err
+	}
+	return notifyPmEvent(&PmEvent{Type: PmEventRead, From: user.ID(), To: other, Time: time.Now().UTC()})
+}
+
+// ReadPm marks as read the pm received by the current user
+// and notifies the read receipt to the conversation
+func (user *User) ReadPm(pm *Pm) error {
+	if pm.To != user.ID() {
+		return errors.New("you can't mark as read a pm you didn't receive")
+	}
+	if err := Db().Exec(`UPDATE pms SET to_read = FALSE WHERE pmid = ?`, pm.ID()); err != nil {
+		return err
+	}
+	pm.ToRead = false
+	return notifyPmEvent(&PmEvent{Type: PmEventRead, From: user.ID(), To: pm.From, Pmid: pm.ID(), Time: time.Now().UTC()})
+}
+
+// UnreadConversations returns the number of unread pms received by the current user,
+// grouped by sender, the conversation with the most recent unread pm first
+func (user *User) UnreadConversations() (*[]UnreadConversation, error) {
+	var unread []UnreadConversation
+	err := Db().Raw(`SELECT "from", COUNT(*) AS count FROM pms
+	WHERE "to" = ? AND to_read
+	GROUP BY "from"
+	ORDER BY MAX("time") DESC`, user.ID()).Scan(&unread)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &unread, nil
+}
+
+// Typing notifies the conversation with the other user that the current user is typing a pm
+func (user *User) Typing(other uint64) error {
+	return notifyPmEvent(&PmEvent{Type: PmEventTyping, From: user.ID(), To: other, Time: time.Now().UTC()})
 }
 
 // DeleteConversation deletes the conversation of user with other user
@@ -461,6 +603,11 @@ func (user *User) Info() *Info {
 
 // Postlist returns the specified slice of post on the user board
 func (user *User) Postlist(options PostlistOptions) *[]ExistingPost {
+	var retPosts []ExistingPost
+	if options.Viewer != nil && !options.Viewer.CanSee(user) {
+		return &retPosts
+	}
+
 	users := User{}.TableName()
 	var post UserPost
 
@@ -476,7 +623,6 @@ func (user *User) Postlist(options PostlistOptions) *[]ExistingPost {
 		log.Errorf("(User::Postlist) Error in query.Scan: %s", err)
 	}
 
-	var retPosts []ExistingPost
 	for _, p := range userPosts {
 		userPost := p
 		retPosts = append(retPosts, ExistingPost(&userPost))
@@ -494,37 +640,123 @@ func (user *User) Add(message newMessage) error {
 		if message.To == 0 {
 			message.To = user.ID()
 		}
+		if board, err := NewUser(message.To); err != nil || !user.CanWrite(board) {
+			return errors.New("you can't write posts on this board")
+		}
 		if err := createMessage(message, user.ID(), message.To, message.Text(), message.Language()); err != nil {
 			return err
 		}
 
-		return Db().Create(message)
+		if err := Db().Create(message); err != nil {
+			return err
+		}
+		notifyEvent(UserPostsChannel(message.To), message.Hpid)
+		post := *message
+		enqueueWebhooks(func() {
+			toPostTO := func(user *User) interface{} { return post.GetTO(user) }
+			triggerWebhooks(WebhookEventPost, post.To, toPostTO)
+			triggerMentionWebhooks("u_hpid", post.Hpid, post.From, post.Time, toPostTO)
+		})
+		return nil
 
 	case *ProjectPost:
 		if err := createMessage(message, user.ID(), message.To, message.Text(), message.Language()); err != nil {
 			return err
 		}
 
-		return Db().Create(message)
+		if err := Db().Create(message); err != nil {
+			return err
+		}
+		notifyEvent(ProjectPostsChannel(message.To), message.Hpid)
+		post := *message
+		enqueueWebhooks(func() {
+			toPostTO := func(user *User) interface{} { return post.GetTO(user) }
+			triggerProjectWebhooks(WebhookEventPost, post.To, post.From, toPostTO)
+			triggerMentionWebhooks("g_hpid", post.Hpid, post.From, post.Time, toPostTO)
+		})
+		return nil
 
 	case *UserPostComment:
+		if post, err := NewUserPost(message.Hpid); err != nil || !user.CanComment(post) {
+			return errors.New("you can't comment this post")
+		}
 		if err := createMessage(message, user.ID(), message.Hpid, message.Text(), message.Language()); err != nil {
 			return err
 		}
 
-		return Db().Create(message)
+		if err := Db().Create(message); err != nil {
+			return err
+		}
+		notifyEvent(UserPostCommentsChannel(message.Hpid), message.Hcid)
+		comment := *message
+		enqueueWebhooks(func() {
+			toCommentTO := func(user *User) interface{} { return comment.GetTO(user) }
+			if post, err := NewUserPost(comment.Hpid); err == nil && post.From != comment.From {
+				triggerWebhooks(WebhookEventComment, post.From, toCommentTO)
+			}
+			triggerMentionWebhooks("u_hpid", comment.Hpid, comment.From, comment.Time, toCommentTO)
+		})
+		return nil
 
 	case *ProjectPostComment:
+		if post, err := NewProjectPost(message.Hpid); err != nil || !user.CanComment(post) {
+			return errors.New("you can't comment this post")
+		}
 		if err := createMessage(message, user.ID(), message.Hpid, message.Text(), message.Language()); err != nil {
 			return err
 		}
 
-		return Db().Create(message)
+		if err := Db().Create(message); err != nil {
+			return err
+		}
+		notifyEvent(ProjectPostCommentsChannel(message.Hpid), message.Hcid)
+		comment := *message
+		enqueueWebhooks(func() {
+			toCommentTO := func(user *User) interface{} { return comment.GetTO(user) }
+			if post, err := NewProjectPost(comment.Hpid); err == nil && post.From != comment.From {
+				triggerWebhooks(WebhookEventComment, post.From, toCommentTO)
+			}
+			triggerMentionWebhooks("g_hpid", comment.Hpid, comment.From, comment.Time, toCommentTO)
+		})
+		return nil
 
 	case *Pm:
 		if err := createMessage(message, user.ID(), message.To, message.Text(), message.Language()); err != nil {
 			return err
 		}
+		if err := Db().Create(message); err != nil {
+			return err
+		}
+		if err := notifyPmEvent(&PmEvent{
+			Type: PmEventNew,
+			From: message.From,
+			To:   message.To,
+			Pmid: message.Pmid,
+			Time: message.Time,
+		}); err != nil {
+			log.Errorf("Unable to notify pm %d: %s", message.Pmid, err.Error())
+		}
+		pm := *message
+		enqueueWebhooks(func() {
+			triggerWebhooks(WebhookEventPm, pm.To, func(user *User) interface{} { return pm.GetTO(user) })
+		})
+		return nil
+
+	case *PmGroupMessage:
+		group, err := NewPmGroup(message.GroupID)
+		if err != nil {
+			return err
+		}
+		members := group.NumericMembers()
+		if !utils.InSlice(user.ID(), members) {
+			return errors.New("you don't participate in the group conversation")
+		}
+		if err = user.checkPmGroupBlacklist(members); err != nil {
+			return err
+		}
+		if err = createMessage(message, user.ID(), message.GroupID, message.Text(), message.Language()); err != nil {
+			return err
+		}
 		return Db().Create(message)
 	}
 
@@ -551,6 +783,11 @@ func (user *User) Edit(message editingMessage) error {
 			message.SetText(rollBackText)
 			return err
 		}
+		if pm, ok := message.(*Pm); ok {
+			if err := notifyPmEvent(&PmEvent{Type: PmEventEdit, From: pm.From, To: pm.To, Pmid: pm.Pmid, Time: time.Now().UTC()}); err != nil {
+				log.Errorf("Unable to notify the edit of pm %d: %s", pm.Pmid, err.Error())
+			}
+		}
 		return nil
 	}
 	return errors.New("you can't edit this message")
@@ -566,7 +803,15 @@ func (user *User) Follow(board Board) error {
 
 	switch board := board.(type) {
 	case *User:
-		return Db().Create(&UserFollower{From: user.ID(), To: board.ID()})
+		follower := UserFollower{From: user.ID(), To: board.ID()}
+		if err := Db().Create(&follower); err != nil {
+			return err
+		}
+		notifyEvent(FollowingChannel(user.ID()), board.ID())
+		enqueueWebhooks(func() {
+			triggerWebhooks(WebhookEventFollower, follower.To, func(user *User) interface{} { return follower.GetTO(user) })
+		})
+		return nil
 
 	case *Project:
 		return Db().Create(&ProjectFollower{From: user.ID(), To: board.ID()})
@@ -618,7 +863,11 @@ func (user *User) Unfollow(board Board) error {
 
 	switch board := board.(type) {
 	case *User:
-		return Db().Where(&UserFollower{From: user.ID(), To: board.ID()}).Delete(UserFollower{})
+		if err := Db().Where(&UserFollower{From: user.ID(), To: board.ID()}).Delete(UserFollower{}); err != nil {
+			return err
+		}
+		notifyEvent(FollowingChannel(user.ID()), board.ID())
+		return nil
 
 	case *Project:
 		return Db().Where(&ProjectFollower{From: user.ID(), To: board.ID()}).Delete(ProjectFollower{})
@@ -821,6 +1070,25 @@ func (user *User) DeleteInterest(interest *Interest) error {
 	return Db().Where(&toDelete).Delete(Interest{})
 }
 
+// InterestedUsers returns the users, but the user, that share the interest (case insensitive),
+// the most recently registered first. The users that are in the user blacklist or that
+// put the user in their blacklist are excluded
+func (user *User) InterestedUsers(value string, options UsersOptions) []*User {
+	query := Db().Model(Interest{}).Where(
+		`LOWER(value) = LOWER(?) AND "from" <> ?
+		AND "from" NOT IN (SELECT "to" FROM blacklist WHERE "from" = ?)
+		AND "from" NOT IN (SELECT "from" FROM blacklist WHERE "to" = ?)`, value, user.ID(), user.ID(), user.ID())
+	if options.Older != 0 {
+		query = query.Where(`"from" < ?`, options.Older)
+	}
+
+	var users []uint64
+	if err := query.Order(`"from" DESC`).Limit(int(AtMostUsers(uint64(options.N)))).Pluck(`"from"`, &users); err != nil {
+		log.Errorf("(InterestedUsers) Error in query.Pluck: %s", err)
+	}
+	return Users(users)
+}
+
 // Friends returns the current user's friends
 func (user *User) Friends() []*User {
 	return Users(user.NumericFriends())
@@ -842,6 +1110,18 @@ func (user *User) Language() string {
 
 // CanEdit returns true if user can edit the editingMessage
 func (user *User) CanEdit(message editingMessage) bool {
+	// only the sender can edit a pm, while both the users of the conversation own it
+	// the content of a project can be edited by the sender and by the roles with the permission to edit
+	switch message := message.(type) {
+	case *Pm:
+		return message.ID() > 0 && message.From == user.ID()
+	case *ProjectPost:
+		return message.ID() > 0 && message.IsEditable() &&
+			(message.From == user.ID() || projectHasPermission(message.To, user, ProjectPermissionEdit))
+	case *ProjectPostComment:
+		return message.ID() > 0 && message.IsEditable() &&
+			(message.From == user.ID() || projectHasPermission(message.To, user, ProjectPermissionEdit))
+	}
 	return message.ID() > 0 && message.IsEditable() && utils.InSlice(user.ID(), message.NumericOwners())
 }
 
@@ -860,16 +1140,49 @@ func (user *User) CanLurk(message ExistingPost) bool {
 	return message.ID() > 0 && !utils.InSlice(user.ID(), message.NumericLurkers())
 }
 
-// CanComment returns true if the user can comment to the existingPost
+// CanComment returns true if the user can comment to the existingPost.
+// The closed posts of a project can be commented by the roles with the permission to comment them,
+// the posts of a whitelist-only user board only by the owner and the whitelisted users
 func (user *User) CanComment(message ExistingPost) bool {
-	return !utils.InSlice(user.ID(), message.Sender().NumericBlacklist()) && message.ID() > 0 && !message.IsClosed()
+	if message.ID() == 0 || utils.InSlice(user.ID(), message.Sender().NumericBlacklist()) {
+		return false
+	}
+	switch post := message.(type) {
+	case *ProjectPost:
+		project, err := NewProject(post.To)
+		if err != nil || !user.CanSee(project) {
+			return false
+		}
+		return !post.IsClosed() || project.HasPermission(user, ProjectPermissionComment)
+
+	case *UserPost:
+		board, err := NewUser(post.To)
+		if err != nil || !user.CanSee(board) {
+			return false
+		}
+		if board.Profile.WhitelistOnly && !utils.InSlice(user.ID(), board.NumericWhitelist()) {
+			return false
+		}
+	}
+	return !message.IsClosed()
+}
+
+// CanSeeProfile returns true if the user can see the profile of the other user, and thus
+// follow, blacklist and write pms to the other user: only the users blacklisted by the other user can't.
+// The content of a private board is visible only to the users satisfying CanSee
+func (user *User) CanSeeProfile(other *User) bool {
+	return !utils.InSlice(user.ID(), other.NumericBlacklist())
 }
 
 // CanSee returns true if the user can see the Board content
 func (user *User) CanSee(board Board) bool {
 	switch board := board.(type) {
 	case *User:
-		return !utils.InSlice(user.ID(), board.NumericBlacklist())
+		if !user.CanSeeProfile(board) {
+			return false
+		}
+		// a private board is visible only by its owner and the whitelisted users
+		return user.ID() == board.ID() || !board.Private || utils.InSlice(user.ID(), board.NumericWhitelist())
 
 	case *Project:
 		if board.Visible {
@@ -204,6 +204,12 @@ func (post *UserPost) Revisions() (modifications []string) {
 	return
 }
 
+// History returns the revisions of the message, from the first one
+func (post *UserPost) History() (revisions []UserPostRevision) {
+	_ = Db().Model(UserPostRevision{}).Where(&UserPostRevision{Hpid: post.ID()}).Order("rev_no").Scan(&revisions)
+	return
+}
+
 // RevisionsNumber returns the number of the revisions
 func (post *UserPost) RevisionsNumber() (count uint8) {
 	_ = Db().Model(UserPostRevision{}).Where(&UserPostRevision{Hpid: post.ID()}).Count(&count)
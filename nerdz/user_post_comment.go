@@ -165,6 +165,12 @@ func (comment *UserPostComment) Revisions() (modifications []string) {
 	return
 }
 
+// History returns the revisions of the message, from the first one
+func (comment *UserPostComment) History() (revisions []UserPostCommentRevision) {
+	_ = Db().Model(UserPostCommentRevision{}).Where(&UserPostCommentRevision{Hcid: comment.Hcid}).Order("rev_no").Scan(&revisions)
+	return
+}
+
 // RevisionsNumber returns the number of the revisions
 func (comment *UserPostComment) RevisionsNumber() (count uint8) {
 	_ = Db().Model(UserPostCommentRevision{}).Where(&UserPostCommentRevision{Hcid: comment.Hcid}).Count(&count)
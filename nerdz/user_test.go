@@ -20,10 +20,12 @@ package nerdz_test
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/utils"
 )
 
 var me, other, blacklisted, withClosedProfile *nerdz.User
@@ -88,6 +90,108 @@ func TestPersonalInfo(t *testing.T) {
 	}
 }
 
+func TestPresence(t *testing.T) {
+	user, _ := nerdz.NewUser(me.ID())
+	user.Last = time.Now().UTC().Add(-nerdz.OnlineTimeout)
+	if err := user.Touch(); err != nil {
+		t.Fatalf("Touch should work, but got: %s", err.Error())
+	}
+
+	user, _ = nerdz.NewUser(me.ID())
+	presence := user.Presence()
+	if presence.Online != user.Viewonline {
+		t.Errorf("User(%d) should be online only if the online status is visible, got %+v", user.ID(), presence)
+	}
+
+	user.Viewonline = false
+	if presence = user.Presence(); presence.Online || !presence.Last.IsZero() {
+		t.Errorf("User(%d) hides the online status, but got %+v", user.ID(), presence)
+	}
+}
+
+func TestUpdateInfo(t *testing.T) {
+	user, _ := nerdz.NewUser(me.ID())
+
+	update := *user
+	update.Timezone = "Mars/Olympus_Mons"
+	if err := user.UpdateInfo(&update); err == nil {
+		t.Error("UpdateInfo should fail with an invalid timezone")
+	}
+
+	update = *user
+	update.Lang = "klingon"
+	if err := user.UpdateInfo(&update); err == nil {
+		t.Error("UpdateInfo should fail with an invalid language")
+	}
+
+	update = *user
+	update.Name = "  "
+	if err := user.UpdateInfo(&update); err == nil {
+		t.Error("UpdateInfo should fail with an empty name")
+	}
+
+	update = *user
+	update.Timezone = "Europe/Rome"
+	update.Viewonline = !user.Viewonline
+	if err := user.UpdateInfo(&update); err != nil {
+		t.Fatalf("UpdateInfo should work, but got: %s", err.Error())
+	}
+
+	stored, _ := nerdz.NewUser(me.ID())
+	if stored.Timezone != "Europe/Rome" || stored.Viewonline != update.Viewonline {
+		t.Errorf("Expected timezone Europe/Rome and viewonline %t, got %s and %t", update.Viewonline, stored.Timezone, stored.Viewonline)
+	}
+
+	// restore the original values
+	if err := user.UpdateInfo(me); err != nil {
+		t.Fatalf("UpdateInfo should work, but got: %s", err.Error())
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	user, _ := nerdz.NewUser(me.ID())
+
+	profile := user.Profile
+	profile.Website = "ftp://nerdz.eu"
+	if err := user.UpdateProfile(&profile); err == nil {
+		t.Error("UpdateProfile should fail with a non http website")
+	}
+
+	profile = user.Profile
+	profile.Github = "https://gitlab.com/nerdzeu"
+	if err := user.UpdateProfile(&profile); err == nil {
+		t.Error("UpdateProfile should fail with a GitHub URL not on github.com")
+	}
+
+	profile = user.Profile
+	profile.Biography = "Biography updated by the test"
+	profile.Github = "https://github.com/nerdzeu"
+	if err := user.UpdateProfile(&profile); err != nil {
+		t.Fatalf("UpdateProfile should work, but got: %s", err.Error())
+	}
+
+	stored, _ := nerdz.NewUser(me.ID())
+	if stored.Profile.Biography != profile.Biography || stored.Profile.Github != profile.Github {
+		t.Errorf("Expected the updated profile, got %+v", stored.Profile)
+	}
+
+	// a stored value that's not valid anymore doesn't prevent the update of the other fields
+	if err := nerdz.Db().Exec(`UPDATE profiles SET website = ? WHERE counter = ?`, "www.nerdz.eu", user.ID()); err != nil {
+		t.Fatal(err)
+	}
+	user, _ = nerdz.NewUser(me.ID())
+	profile = user.Profile
+	profile.Quotes = "Quotes updated by the test"
+	if err := user.UpdateProfile(&profile); err != nil {
+		t.Fatalf("UpdateProfile of the quotes only should work, but got: %s", err.Error())
+	}
+
+	// restore the original values
+	if err := user.UpdateProfile(&me.Profile); err != nil {
+		t.Fatalf("UpdateProfile should work, but got: %s", err.Error())
+	}
+}
+
 func TestBoardInfo(t *testing.T) {
 	info := me.BoardInfo()
 	if info == nil {
@@ -337,9 +441,40 @@ func TestAddEditDeletePm(t *testing.T) {
 		t.Fatalf("No errors should occur while adding a new pm to a non blacklisted user, but got %v", err)
 	}
 
-	pm.Message = "Pm edit is impossible (since in IM messages are not editable)"
-	if err := me.Edit(&pm); err == nil {
-		t.Fatalf("Pm edit shouldn't work")
+	original := pm.Message
+	pm.Message = "Hi bro. Join telegram now, please"
+	if err := withClosedProfile.Edit(&pm); err == nil {
+		t.Fatalf("Pm edit by the recipient shouldn't work")
+	}
+
+	if err := me.Edit(&pm); err != nil {
+		t.Fatalf("Pm edit by the sender should work, but got: %s", err.Error())
+	}
+
+	if revisions := pm.Revisions(); len(revisions) != 1 || revisions[0] != original {
+		t.Fatalf("Expected the original message as the only revision, but got %v", revisions)
+	}
+
+	if history := pm.History(); len(history) != 1 || history[0].Message != original || history[0].GetTO(me).Pmid != pm.ID() {
+		t.Fatalf("Expected the original message as the only entry of the history, but got %v", history)
+	}
+
+	if err := me.ReadPm(&pm); err == nil {
+		t.Fatalf("Pm read by the sender shouldn't work")
+	}
+
+	if err := withClosedProfile.ReadPm(&pm); err != nil {
+		t.Fatalf("Pm read by the recipient should work, but got: %s", err.Error())
+	}
+
+	unread, err := withClosedProfile.UnreadConversations()
+	if err != nil {
+		t.Fatalf("UnreadConversations should work, but got: %s", err.Error())
+	}
+	for _, conversation := range *unread {
+		if conversation.From == me.ID() {
+			t.Fatalf("The conversation with user(%d) should be read", me.ID())
+		}
 	}
 
 	if err := me.Delete(&pm); err != nil {
@@ -501,6 +636,80 @@ func TestProjectPostUnbookmark(t *testing.T) {
 	}
 }
 
+func TestBookmarksAndLurks(t *testing.T) {
+	userPost, _ := nerdz.NewUserPost(13)
+	projectPost, _ := nerdz.NewProjectPost(2)
+
+	if _, err := me.Bookmark(userPost); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = me.Unbookmark(userPost) }()
+	if _, err := me.Bookmark(projectPost); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = me.Unbookmark(projectPost) }()
+
+	bookmarks := *me.Bookmarks(nerdz.PostlistOptions{N: 20})
+	var user, project bool
+	for i, post := range bookmarks {
+		if i > 0 && post.Time.After(bookmarks[i-1].Time) {
+			t.Errorf("Bookmarks are not ordered from the most recent")
+		}
+		user = user || (post.Type == 1 && post.Hpid == userPost.Hpid)
+		project = project || (post.Type == 0 && post.Hpid == projectPost.Hpid)
+	}
+	if !user || !project {
+		t.Errorf("Bookmarks should contain the user post %d and the project post %d, got %v", userPost.Hpid, projectPost.Hpid, bookmarks)
+	}
+
+	if bookmarks = *me.Bookmarks(nerdz.PostlistOptions{N: 1}); len(bookmarks) != 1 {
+		t.Errorf("Expected 1 bookmark, got %d", len(bookmarks))
+	}
+
+	for _, post := range *me.Lurks(nerdz.PostlistOptions{N: 20}) {
+		var lurkers []uint64
+		if post.Type == 1 {
+			lurkers = (&nerdz.UserPost{Post: post.Post}).NumericLurkers()
+		} else {
+			lurkers = (&nerdz.ProjectPost{Post: post.Post}).NumericLurkers()
+		}
+		if !utils.InSlice(me.Counter, lurkers) {
+			t.Errorf("User(%d) doesn't lurk the post %d", me.Counter, post.Hpid)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	mentions, err := me.Mentions(nerdz.MentionsOptions{N: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mention := range *mentions {
+		if mention.To != me.Counter {
+			t.Errorf("Mention %d is not for User(%d)", mention.ID, me.Counter)
+		}
+		if i > 0 && mention.ID > (*mentions)[i-1].ID {
+			t.Errorf("Mentions are not ordered from the most recent")
+		}
+		if mention.UHpid == 0 && mention.GHpid == 0 {
+			t.Errorf("Mention %d doesn't reference a post", mention.ID)
+		}
+		if board, err := mention.Board(); err != nil || !me.CanSee(board) {
+			t.Errorf("Mention %d is on a board that User(%d) can't see", mention.ID, me.Counter)
+		}
+	}
+
+	if err = me.ReadMentions(); err != nil {
+		t.Fatal(err)
+	}
+	if mentions, err = me.Mentions(nerdz.MentionsOptions{Unread: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*mentions) != 0 {
+		t.Errorf("Expected no unread mentions after ReadMentions, got %d", len(*mentions))
+	}
+}
+
 func TestPms(t *testing.T) {
 	other, _ = nerdz.NewUser(2)
 	t.Logf("User(%d) pm-> User(%d)", me.Counter, other.Counter)
@@ -545,6 +754,110 @@ func TestConversation(t *testing.T) {
 	t.Logf("####################################")
 }
 
+func TestReadConversation(t *testing.T) {
+	pm := nerdz.Pm{Message: "Did you read it?", To: me.ID()}
+	if err := withClosedProfile.Add(&pm); err != nil {
+		t.Fatalf("No errors should occur while adding a new pm, but got %v", err)
+	}
+
+	if err := me.ReadConversation(withClosedProfile.ID()); err != nil {
+		t.Fatalf("Conversation should be marked as read, but got: %s", err.Error())
+	}
+
+	convList, err := me.Conversations()
+	if err != nil {
+		t.Fatalf("No private conversations available for user(%d)", me.ID())
+	}
+	for _, conversation := range *convList {
+		if conversation.To == withClosedProfile.ID() && conversation.ToRead {
+			t.Fatalf("Conversation with user(%d) should be read", withClosedProfile.ID())
+		}
+	}
+
+	if err := withClosedProfile.Delete(&pm); err != nil {
+		t.Fatalf("Pm delete failed with error: %s", err.Error())
+	}
+}
+
+func TestPmGroup(t *testing.T) {
+	if _, err := me.CreatePmGroup(" ", []uint64{other.ID()}); err == nil {
+		t.Fatalf("CreatePmGroup should fail with an empty name")
+	}
+	if _, err := me.CreatePmGroup("Alone", []uint64{me.ID()}); err == nil {
+		t.Fatalf("CreatePmGroup should fail without other participants")
+	}
+
+	group, err := me.CreatePmGroup("Team", []uint64{other.ID()})
+	if err != nil {
+		t.Fatalf("CreatePmGroup should work, but got: %s", err.Error())
+	}
+	defer nerdz.Db().Delete(group)
+
+	if !group.HasAdmin(me) || group.HasAdmin(other) || !group.HasMember(other) {
+		t.Fatalf("The creator should be the only admin, got admins %v and members %v", group.NumericAdmins(), group.NumericMembers())
+	}
+
+	if err = other.AddPmGroupMember(group, withClosedProfile); err == nil {
+		t.Fatalf("AddPmGroupMember by a participant that's not an admin shouldn't work")
+	}
+	if err = me.AddPmGroupMember(group, withClosedProfile); err != nil {
+		t.Fatalf("AddPmGroupMember by the admin should work, but got: %s", err.Error())
+	}
+	if err = me.BlacklistUser(blacklisted, "group test"); err == nil {
+		defer me.UnblacklistUser(blacklisted)
+	}
+	if err = me.AddPmGroupMember(group, blacklisted); err == nil {
+		t.Fatalf("AddPmGroupMember of a user blacklisted by a participant shouldn't work")
+	}
+
+	message := nerdz.PmGroupMessage{GroupID: group.ID, Message: "Hi team"}
+	if err = blacklisted.Add(&message); err == nil {
+		t.Fatalf("Add of a message by a user that doesn't participate shouldn't work")
+	}
+	if err = other.Add(&message); err != nil {
+		t.Fatalf("Add of a message by a participant should work, but got: %s", err.Error())
+	}
+	if messages, err := group.Messages(nerdz.PmsOptions{N: 10}); err != nil || len(*messages) != 1 {
+		t.Fatalf("Expected 1 message in the group conversation, got %v (%v)", messages, err)
+	}
+
+	toRead := func() bool {
+		convList, err := me.Conversations()
+		if err != nil {
+			t.Fatalf("Conversations should work, but got: %s", err.Error())
+		}
+		for _, conversation := range *convList {
+			if conversation.Group == group.ID {
+				if conversation.From != other.ID() || conversation.LastMessage != message.Message {
+					t.Fatalf("Expected the last message of the group conversation, got %+v", conversation)
+				}
+				return conversation.ToRead
+			}
+		}
+		t.Fatalf("The group conversation should be in the conversations of user(%d)", me.ID())
+		return false
+	}
+	if !toRead() {
+		t.Fatalf("The group conversation should be to read")
+	}
+	if err = me.ReadPmGroup(group); err != nil {
+		t.Fatalf("ReadPmGroup should work, but got: %s", err.Error())
+	}
+	if toRead() {
+		t.Fatalf("The group conversation should be read")
+	}
+
+	if err = me.SetPmGroupAdmin(group, me, false); err == nil {
+		t.Fatalf("SetPmGroupAdmin shouldn't revoke the role of the last admin")
+	}
+	if err = me.LeavePmGroup(group); err != nil {
+		t.Fatalf("LeavePmGroup should work, but got: %s", err.Error())
+	}
+	if group.HasMember(me) || !group.HasAdmin(other) {
+		t.Fatalf("The oldest participant should be the admin after the admin left, got admins %v", group.NumericAdmins())
+	}
+}
+
 func TestDoVotes(t *testing.T) {
 	userPost, _ := nerdz.NewUserPost(13)
 	votesCount := userPost.VotesCount()
@@ -600,3 +913,172 @@ func TestInterests(t *testing.T) {
 		t.Fatalf("DeleteInterest shoud not fail, but got: %v", err)
 	}
 }
+
+func TestInterestedUsers(t *testing.T) {
+	otherIn := nerdz.Interest{Value: "Shared Interest"}
+	if err := other.AddInterest(&otherIn); err != nil {
+		t.Fatalf("AddInterest shoud not fail, but got: %v", err)
+	}
+	defer other.DeleteInterest(&otherIn)
+
+	closedIn := nerdz.Interest{Value: "shared interest"}
+	if err := withClosedProfile.AddInterest(&closedIn); err != nil {
+		t.Fatalf("AddInterest shoud not fail, but got: %v", err)
+	}
+	defer withClosedProfile.DeleteInterest(&closedIn)
+
+	users := me.InterestedUsers("SHARED interest", nerdz.UsersOptions{N: 10})
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users sharing the interest, got %d", len(users))
+	}
+	if users[0].ID() != withClosedProfile.ID() || users[1].ID() != other.ID() {
+		t.Errorf("Expected users %d and %d, got %d and %d", withClosedProfile.ID(), other.ID(), users[0].ID(), users[1].ID())
+	}
+
+	users = me.InterestedUsers("shared interest", nerdz.UsersOptions{N: 1, Older: withClosedProfile.ID()})
+	if len(users) != 1 || users[0].ID() != other.ID() {
+		t.Errorf("Expected only user %d registered before user %d", other.ID(), withClosedProfile.ID())
+	}
+}
+
+func TestSearch(t *testing.T) {
+	if _, err := me.Search(nerdz.SearchOptions{Query: " "}); err == nil {
+		t.Fatalf("Search should fail with an empty query")
+	}
+
+	post := nerdz.UserPost{}
+	post.Message = "Searching for the zygomorphic flowers"
+	if err := me.Add(&post); err != nil {
+		t.Fatalf("No errors should occur while adding a new post, but got %v", err)
+	}
+	defer me.Delete(&post)
+
+	hidden := nerdz.UserPost{}
+	hidden.Message = "Nobody will find these zygomorphic flowers"
+	if err := blacklisted.Add(&hidden); err != nil {
+		t.Fatalf("No errors should occur while adding a new post, but got %v", err)
+	}
+	defer blacklisted.Delete(&hidden)
+	if err := me.BlacklistUser(blacklisted, "search test"); err == nil {
+		defer me.UnblacklistUser(blacklisted)
+	}
+
+	results, err := me.Search(nerdz.SearchOptions{Query: "zygomorphic", Types: []string{nerdz.SearchUserPosts}})
+	if err != nil {
+		t.Fatalf("Search should work, but got: %s", err.Error())
+	}
+	if len(*results) != 1 {
+		t.Fatalf("Expected 1 result, got %d: %+v", len(*results), *results)
+	}
+	result := (*results)[0]
+	if result.ID != post.Hpid || !strings.Contains(result.Snippet, "<mark>zygomorphic</mark>") {
+		t.Fatalf("Expected the post %d with a highlighted snippet, got %+v", post.Hpid, result)
+	}
+	if to := result.GetTO(me); to.Post == nil || to.Post.Hpid != post.Hpid {
+		t.Fatalf("Expected the post in the search result TO, got %+v", to)
+	}
+
+	if results, err = me.Search(nerdz.SearchOptions{Query: "zygomorphic", Author: other.ID()}); err != nil || len(*results) != 0 {
+		t.Fatalf("Expected no results for another author, got %v (%v)", results, err)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	users := me.SearchUsers(nerdz.DirectoryOptions{Query: strings.ToUpper(me.Username), Exact: true})
+	if len(users) != 1 || users[0].ID() != me.ID() {
+		t.Fatalf("Expected user(%d) by exact username, got %v", me.ID(), users)
+	}
+
+	found := false
+	for _, user := range me.SearchUsers(nerdz.DirectoryOptions{Query: me.Username[:2], Autocomplete: true, N: 50}) {
+		if !strings.HasPrefix(strings.ToLower(user.Username), strings.ToLower(me.Username[:2])) {
+			t.Fatalf("Autocomplete should return only the usernames starting with %s, got %s", me.Username[:2], user.Username)
+		}
+		if !me.CanSee(user) {
+			t.Fatalf("Autocomplete should return only the users that user(%d) can see, got %s", me.ID(), user.Username)
+		}
+		found = found || user.ID() == me.ID()
+	}
+	if !found {
+		t.Fatalf("Autocomplete of %s should return user(%d)", me.Username[:2], me.ID())
+	}
+
+	if users = me.SearchUsers(nerdz.DirectoryOptions{Query: "%"}); len(users) != 0 {
+		t.Fatalf("The LIKE special characters should be escaped, got %d users", len(users))
+	}
+}
+
+func TestPrivateBoardOwner(t *testing.T) {
+	original := *me.BoardSettings()
+	defer func() { _ = me.UpdateBoardSettings(&original) }()
+
+	if err := me.UpdateBoardSettings(&nerdz.BoardSettings{Private: true}); err != nil {
+		t.Fatalf("UpdateBoardSettings should work, but got: %v", err)
+	}
+	if !me.CanSee(me) {
+		t.Errorf("The owner of a private board should see it")
+	}
+	post := nerdz.UserPost{}
+	post.Message = "Post on my private board"
+	if err := me.Add(&post); err != nil {
+		t.Fatalf("The owner should be able to write on the private board, but got: %v", err)
+	}
+	defer func() { _ = me.Delete(&post) }()
+	if posts := me.Postlist(nerdz.PostlistOptions{Viewer: me, N: 1}); len(*posts) != 1 || (*posts)[0].ID() != post.ID() {
+		t.Errorf("The owner should see the posts of the private board")
+	}
+}
+
+func TestBoardSettings(t *testing.T) {
+	original := *me.BoardSettings()
+	wasWhitelisted := utils.InSlice(other.ID(), original.Whitelist)
+	defer func() {
+		_ = me.UpdateBoardSettings(&original)
+		if wasWhitelisted {
+			_ = me.WhitelistUser(other)
+		}
+	}()
+	if wasWhitelisted {
+		_ = me.UnwhitelistUser(other)
+	}
+
+	if err := me.UpdateBoardSettings(&nerdz.BoardSettings{Private: true, Closed: true}); err != nil {
+		t.Fatalf("UpdateBoardSettings should work, but got: %v", err)
+	}
+	if !me.CanSee(me) || other.CanSee(me) {
+		t.Errorf("A private board should be visible only by its owner and the whitelisted users")
+	}
+	if !me.CanSeeProfile(me) || !other.CanSeeProfile(me) {
+		t.Errorf("The profile of a private user should be visible, to follow and write pms to the user")
+	}
+	if posts := me.Postlist(nerdz.PostlistOptions{Viewer: other}); len(*posts) != 0 {
+		t.Errorf("The postlist of a private board should be empty for the other users, but got %d posts", len(*posts))
+	}
+	post := nerdz.UserPost{}
+	post.To = me.ID()
+	post.Message = "Post on a closed board"
+	if err := other.Add(&post); err == nil {
+		_ = other.Delete(&post)
+		t.Errorf("Only the whitelisted users should be able to write on a closed board")
+	}
+
+	if err := me.WhitelistUser(other); err != nil {
+		t.Fatalf("WhitelistUser should work, but got: %v", err)
+	}
+	if !other.CanSee(me) || !other.CanWrite(me) {
+		t.Errorf("A whitelisted user should see and write on the private and closed board")
+	}
+	if err := me.UnwhitelistUser(other); err != nil {
+		t.Fatalf("UnwhitelistUser should work, but got: %v", err)
+	}
+
+	if err := me.UpdateBoardSettings(&nerdz.BoardSettings{WhitelistOnly: true}); err != nil {
+		t.Fatalf("UpdateBoardSettings should work, but got: %v", err)
+	}
+	if !other.CanSee(me) || other.CanWrite(me) {
+		t.Errorf("A whitelist-only board should be visible, but not writable, by the other users")
+	}
+	if settings := me.BoardSettings(); !settings.WhitelistOnly || settings.Private || settings.Closed {
+		t.Errorf("Unexpected board settings: %+v", settings)
+	}
+}
@@ -72,3 +72,28 @@ func AtMostComments(n uint64) uint8 {
 func AtMostPms(n uint64) uint8 {
 	return uint8(utils.AtMost(n, MinPms, MaxPms))
 }
+
+// AtMostWebhookDeliveries returns a uint8 that's the number of webhook deliveries to be retrieved
+func AtMostWebhookDeliveries(n uint64) uint8 {
+	return uint8(utils.AtMost(n, MinWebhookDeliveries, MaxWebhookDeliveries))
+}
+
+// AtMostUsers returns a uint8 that's the number of users to be retrieved
+func AtMostUsers(n uint64) uint8 {
+	return uint8(utils.AtMost(n, MinUsers, MaxUsers))
+}
+
+// AtMostMentions returns a uint8 that's the number of mentions to be retrieved
+func AtMostMentions(n uint64) uint8 {
+	return uint8(utils.AtMost(n, MinMentions, MaxMentions))
+}
+
+// AtMostSearchResults returns a uint8 that's the number of search results to be retrieved
+func AtMostSearchResults(n uint64) uint8 {
+	return uint8(utils.AtMost(n, MinSearchResults, MaxSearchResults))
+}
+
+// AtMostStatsBuckets returns a uint8 that's the number of statistics buckets to be computed
+func AtMostStatsBuckets(n uint64) uint8 {
+	return uint8(utils.AtMost(n, MinStatsBuckets, MaxStatsBuckets))
+}
@@ -0,0 +1,519 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// Events that can be delivered to a webhook
+const (
+	// WebhookEventPost is a new post on the board of the user,
+	// or on the board of a project owned by the user or of which the user is a member
+	WebhookEventPost = "post"
+	// WebhookEventComment is a new comment on a post of the user
+	WebhookEventComment = "comment"
+	// WebhookEventMention is a mention of the user in a post or in a comment
+	WebhookEventMention = "mention"
+	// WebhookEventFollower is a new follower of the user
+	WebhookEventFollower = "follower"
+	// WebhookEventPm is a new pm sent to the user
+	WebhookEventPm = "pm"
+)
+
+// Status of a webhook delivery
+const (
+	// WebhookDeliveryPending is the status of a delivery not yet delivered, that will be retried
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered is the status of a delivery accepted by the receiver
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryDead is the status of a delivery that failed too many times. It's in the dead letters
+	WebhookDeliveryDead = "dead"
+)
+
+// Headers of the webhook requests
+const (
+	// WebhookEventHeader contains the event of the delivery
+	WebhookEventHeader = "X-Nerdz-Event"
+	// WebhookDeliveryHeader contains the ID of the delivery
+	WebhookDeliveryHeader = "X-Nerdz-Delivery"
+	// WebhookSignatureHeader contains the signature of the body, generated by SignWebhookPayload
+	WebhookSignatureHeader = "X-Nerdz-Signature"
+)
+
+const (
+	// MinWebhookDeliveries represents the minimum deliveries number that can be required in the delivery log
+	MinWebhookDeliveries uint64 = 1
+	// MaxWebhookDeliveries represents the maximum deliveries number that can be required in the delivery log
+	MaxWebhookDeliveries uint64 = 50
+)
+
+const (
+	// webhookMaxAttempts is the number of failed attempts after which the delivery goes to the dead letters
+	webhookMaxAttempts = 8
+	// webhookRetryDelay is the delay before the first retry. It doubles after every failed attempt
+	webhookRetryDelay = 30 * time.Second
+	// webhookTimeout is the time allowed to the receiver to reply
+	webhookTimeout = 10 * time.Second
+	// webhookClaimBatch is the number of pending deliveries claimed at once by DeliverPendingWebhooks
+	webhookClaimBatch = 10
+	// webhookClaimLease is the time a claimed delivery is reserved to the instance that claimed it.
+	// If the instance dies while delivering, the delivery is attempted again when the lease expires
+	webhookClaimLease = webhookClaimBatch*webhookTimeout + time.Minute
+)
+
+// webhookScopes contains, for every event, the scope that the user must grant
+// to the client to let its webhooks receive the event
+var webhookScopes = map[string]string{
+	WebhookEventPost:     "profile_messages:read",
+	WebhookEventComment:  "profile_comments:read",
+	WebhookEventMention:  "notifications:read",
+	WebhookEventFollower: "followers:read",
+	WebhookEventPm:       "pms:read",
+}
+
+// webhookClient is the HTTP client used to deliver the events.
+// It refuses to connect to non public addresses, even if the host of the webhook
+// resolves to one of them after the registration of the webhook
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isWebhookAddress(ip) {
+					return fmt.Errorf("the address %s is not public", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+	},
+}
+
+// webhookAllowPrivate, when true, lets the webhooks point to non public addresses
+var webhookAllowPrivate bool
+
+// isWebhookAddress returns true if a webhook can be delivered to the ip:
+// the loopback, private, link-local and unspecified addresses are not allowed
+func isWebhookAddress(ip net.IP) bool {
+	return webhookAllowPrivate || !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+// webhookWakeup wakes up DeliverWebhooks when new deliveries are created
+var webhookWakeup = make(chan struct{}, 1)
+
+// webhookTriggersQueue is the number of triggers that can be enqueued
+// before enqueueWebhooks blocks the caller
+const webhookTriggersQueue = 1024
+
+var (
+	// webhookTriggers is the queue of the triggers run, in order, by the webhooks worker
+	webhookTriggers = make(chan func(), webhookTriggersQueue)
+	// webhookTriggersWorker starts the webhooks worker once
+	webhookTriggersWorker sync.Once
+	// webhookTriggersPending counts the enqueued triggers not yet run
+	webhookTriggersPending sync.WaitGroup
+)
+
+// enqueueWebhooks enqueues the trigger of some webhooks, thus the deliveries are created
+// by the webhooks worker outside of the action that generates the events
+func enqueueWebhooks(trigger func()) {
+	webhookTriggersWorker.Do(func() {
+		go func() {
+			for trigger := range webhookTriggers {
+				trigger()
+				webhookTriggersPending.Done()
+			}
+		}()
+	})
+	webhookTriggersPending.Add(1)
+	webhookTriggers <- trigger
+}
+
+// WebhookPayload is the body of the request sent to a webhook
+type WebhookPayload struct {
+	Event string      `json:"event"`
+	User  uint64      `json:"user"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// NewWebhook returns the webhook with the specified id
+func NewWebhook(id uint64) (*Webhook, error) {
+	return NewWebhookWhere(&Webhook{ID: id})
+}
+
+// NewWebhookWhere returns the *Webhook fetching the first one that matches the description
+func NewWebhookWhere(description *Webhook) (hook *Webhook, e error) {
+	hook = new(Webhook)
+	if e = Db().Model(Webhook{}).Where(description).Scan(hook); e != nil {
+		return nil, e
+	}
+	if hook.ID == 0 {
+		return nil, errors.New("requested Webhook does not exist")
+	}
+	return
+}
+
+// NewOAuth2Client returns the OAuth2 client with the specified id
+func NewOAuth2Client(id uint64) (*OAuth2Client, error) {
+	client := new(OAuth2Client)
+	if err := Db().First(client, id); err != nil {
+		return nil, err
+	}
+	if client.ID == 0 {
+		return nil, errors.New("requested OAuth2Client does not exist")
+	}
+	return client, nil
+}
+
+// Webhooks returns the webhooks registered by the client
+func (d *OAuth2Client) Webhooks() []*Webhook {
+	var hooks []Webhook
+	if err := Db().Model(Webhook{}).Where(&Webhook{ClientID: d.ID}).Order("id").Scan(&hooks); err != nil {
+		log.Errorf("(OAuth2Client::Webhooks) Error in query.Scan: %s", err)
+	}
+
+	var ret []*Webhook
+	for i := range hooks {
+		ret = append(ret, &hooks[i])
+	}
+	return ret
+}
+
+// AddWebhook registers the webhook for the client
+func (d *OAuth2Client) AddWebhook(hook *Webhook) error {
+	if _, ok := webhookScopes[hook.Event]; !ok {
+		return errors.New("invalid event: " + hook.Event)
+	}
+
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the webhook URL must be an absolute http or https URL")
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return errors.New("unable to resolve the webhook host " + u.Hostname())
+	}
+	for _, ip := range ips {
+		if !isWebhookAddress(ip) {
+			return errors.New("the webhook URL must point to a public address")
+		}
+	}
+
+	hook.ID = 0
+	hook.ClientID = d.ID
+	return Db().Create(hook)
+}
+
+// DeleteWebhook removes the webhook of the client, together with its deliveries
+func (d *OAuth2Client) DeleteWebhook(hook *Webhook) error {
+	if hook.ClientID != d.ID {
+		return errors.New("the webhook is not registered by this client")
+	}
+	return Db().Delete(hook)
+}
+
+// Deliveries returns the last n deliveries to the webhook, the most recent first
+func (w *Webhook) Deliveries(n uint8) []*WebhookDelivery {
+	var deliveries []WebhookDelivery
+	if err := Db().Model(WebhookDelivery{}).Where(&WebhookDelivery{WebhookID: w.ID}).Order("id DESC").Limit(int(n)).Scan(&deliveries); err != nil {
+		log.Errorf("(Webhook::Deliveries) Error in query.Scan: %s", err)
+	}
+
+	var ret []*WebhookDelivery
+	for i := range deliveries {
+		ret = append(ret, &deliveries[i])
+	}
+	return ret
+}
+
+// SignWebhookPayload returns the signature of the payload, that's the
+// hex encoded HMAC-SHA256 of the payload, keyed with the client secret, prefixed by "sha256="
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// grantsScope returns true if one of the scope lists, in the format stored with the access tokens,
+// grants the required scope. A scope is granted by itself and by the scopes that imply it
+func grantsScope(scopeLists []string, required string) bool {
+	parts := strings.Split(required, ":")
+	names := []string{parts[0]}
+	switch parts[0] {
+	case "profile_comments":
+		names = append(names, "profile_messages", "messages")
+	case "project_comments":
+		names = append(names, "project_messages", "messages")
+	case "profile_messages", "project_messages":
+		names = append(names, "messages")
+	}
+
+	for _, list := range scopeLists {
+		for _, scope := range strings.Split(list, " ") {
+			granted := strings.Split(scope, ":")
+			if len(granted) == 2 && utils.InSlice(granted[0], names) && utils.InSlice(parts[1], strings.Split(granted[1], ",")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// triggerWebhooks creates a delivery of the event for every webhook registered for the event
+// by the clients that the user authorized with the scope required by the event.
+// data returns the content of the event, as seen by the user.
+// Errors are logged and not returned, since the webhooks are not part of the action that generates the event
+func triggerWebhooks(event string, userID uint64, data func(user *User) interface{}) {
+	var hooks []Webhook
+	if err := Db().Model(Webhook{}).Where(
+		"event = ? AND client_id IN (SELECT client_id FROM oauth2_access WHERE user_id = ?)", event, userID).Scan(&hooks); err != nil && err != sql.ErrNoRows {
+		log.Errorf("Unable to fetch the webhooks of event %s: %s", event, err.Error())
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	user, err := NewUser(userID)
+	if err != nil {
+		log.Errorf("Unable to trigger the webhooks of event %s: %s", event, err.Error())
+		return
+	}
+
+	payload, err := json.Marshal(&WebhookPayload{
+		Event: event,
+		User:  userID,
+		Time:  time.Now().UTC(),
+		Data:  data(user),
+	})
+	if err != nil {
+		log.Errorf("Unable to encode the payload of event %s: %s", event, err.Error())
+		return
+	}
+
+	created := false
+	for _, hook := range hooks {
+		var scopes []string
+		if err = Db().Model(OAuth2AccessData{}).Where(&OAuth2AccessData{ClientID: hook.ClientID, UserID: userID}).Pluck("scope", &scopes); err != nil {
+			log.Errorf("Unable to fetch the scopes granted to client %d: %s", hook.ClientID, err.Error())
+			continue
+		}
+		if !grantsScope(scopes, webhookScopes[event]) {
+			continue
+		}
+
+		if err = Db().Create(&WebhookDelivery{
+			WebhookID: hook.ID,
+			UserID:    userID,
+			Event:     event,
+			Payload:   string(payload),
+		}); err != nil {
+			log.Errorf("Unable to create the delivery of event %s to webhook %d: %s", event, hook.ID, err.Error())
+			continue
+		}
+		created = true
+	}
+
+	if created {
+		select {
+		case webhookWakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// triggerProjectWebhooks triggers the webhooks of the event for the owner and the members
+// of the project, except the sender of the message
+func triggerProjectWebhooks(event string, projectID, sender uint64, data func(user *User) interface{}) {
+	project, err := NewProject(projectID)
+	if err != nil {
+		log.Errorf("Unable to trigger the webhooks of event %s: %s", event, err.Error())
+		return
+	}
+	for _, user := range append(project.NumericMembers(), project.NumericOwner()) {
+		if user != sender {
+			triggerWebhooks(event, user, data)
+		}
+	}
+}
+
+// triggerMentionWebhooks triggers the mention webhooks of the users mentioned by the sender
+// in the message created at time. column is the column of the mentions table
+// that references the post (u_hpid or g_hpid)
+func triggerMentionWebhooks(column string, hpid, sender uint64, at time.Time, data func(user *User) interface{}) {
+	var mentioned []uint64
+	if err := Db().Model(Mention{}).Where(
+		column+` = ? AND "from" = ? AND "time" >= ?`, hpid, sender, at).Pluck(`"to"`, &mentioned); err != nil && err != sql.ErrNoRows {
+		log.Errorf("Unable to fetch the mentions of post %d: %s", hpid, err.Error())
+		return
+	}
+	for _, user := range mentioned {
+		triggerWebhooks(WebhookEventMention, user, data)
+	}
+}
+
+// DeliverWebhooks delivers the pending deliveries until the context is done.
+// The new deliveries are attempted as soon as they're created, the failed
+// ones are retried when due, checking every period.
+func DeliverWebhooks(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		DeliverPendingWebhooks()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-webhookWakeup:
+		}
+	}
+}
+
+// DeliverPendingWebhooks attempts every pending delivery whose next attempt is due.
+// The deliveries are claimed in batches, thus every instance of the API attempts different deliveries.
+// Returns the number of attempted deliveries
+func DeliverPendingWebhooks() int {
+	attempted := 0
+	for {
+		// a claimed delivery is not due until its lease expires
+		var deliveries []WebhookDelivery
+		if err := Db().Raw(`UPDATE oauth2_webhook_deliveries
+		SET next_attempt_at = (now() at time zone 'utc') + ? * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM oauth2_webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= (now() at time zone 'utc')
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, webhook_id, user_id, event, payload, status, attempts, status_code, last_error, created_at, next_attempt_at`,
+			int(webhookClaimLease/time.Second), WebhookDeliveryPending, webhookClaimBatch).Scan(&deliveries); err != nil && err != sql.ErrNoRows {
+			log.Errorf("(DeliverPendingWebhooks) Error in query.Scan: %s", err)
+			return attempted
+		}
+
+		for i := range deliveries {
+			deliveries[i].attempt()
+		}
+		attempted += len(deliveries)
+		if len(deliveries) < webhookClaimBatch {
+			return attempted
+		}
+	}
+}
+
+// attempt sends the delivery to the webhook and updates its status.
+// After webhookMaxAttempts failures the delivery is moved to the dead letters,
+// otherwise the next attempt is scheduled with an exponential backoff
+func (d *WebhookDelivery) attempt() {
+	err := d.send()
+	d.Attempts++
+	if err == nil {
+		d.Status = WebhookDeliveryDelivered
+		d.LastError = ""
+	} else {
+		d.LastError = err.Error()
+		if d.Attempts >= webhookMaxAttempts {
+			d.Status = WebhookDeliveryDead
+			if e := Db().Create(&WebhookDeadLetter{
+				DeliveryID: d.ID,
+				WebhookID:  d.WebhookID,
+				Event:      d.Event,
+				Payload:    d.Payload,
+				Attempts:   d.Attempts,
+				LastError:  d.LastError,
+			}); e != nil {
+				log.Errorf("Unable to move delivery %d to the dead letters: %s", d.ID, e.Error())
+			}
+		} else {
+			d.NextAttemptAt = time.Now().UTC().Add(webhookRetryDelay << (d.Attempts - 1))
+		}
+	}
+
+	// LastError is a blank field when the delivery succeeds, thus it's updated explicitly
+	if e := Db().Exec(`UPDATE oauth2_webhook_deliveries
+		SET status = ?, attempts = ?, status_code = ?, last_error = ?, next_attempt_at = ?
+		WHERE id = ?`, d.Status, d.Attempts, d.StatusCode, d.LastError, d.NextAttemptAt, d.ID); e != nil {
+		log.Errorf("Unable to update delivery %d: %s", d.ID, e.Error())
+	}
+}
+
+// send posts the payload to the webhook URL, signed with the client secret.
+// Only a 2xx status code is considered a success
+func (d *WebhookDelivery) send() error {
+	hook, err := NewWebhook(d.WebhookID)
+	if err != nil {
+		return err
+	}
+	client, err := NewOAuth2Client(hook.ClientID)
+	if err != nil {
+		return err
+	}
+
+	payload := []byte(d.Payload)
+	req, err := http.NewRequest(http.MethodPost, hook.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(client.Secret, payload))
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	d.StatusCode = res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package nerdz_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/openshift/osin"
+)
+
+// webhookApp creates an application of the user "me", authorized by "me" with the pms:read scope
+func webhookApp(t *testing.T, name string) *nerdz.OAuth2Client {
+	app, err := store.CreateClient(&osin.DefaultClient{
+		Secret:      "webhook secret " + name,
+		RedirectUri: "http://localhost/",
+		UserData:    me.Counter,
+	}, name)
+	if err != nil {
+		t.Fatalf("unable to create application %s: %s\n", name, err.Error())
+	}
+
+	if err = store.SaveAccess(&osin.AccessData{
+		Client:       app,
+		AccessToken:  "webhook access token " + name,
+		RefreshToken: "webhook refresh token " + name,
+		ExpiresIn:    int32(60),
+		Scope:        "pms:read",
+		RedirectUri:  "http://localhost/",
+		UserData:     me.Counter,
+	}); err != nil {
+		t.Fatalf("SaveAccess should work but got: %s\n", err.Error())
+	}
+	return app
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	type received struct {
+		event, signature string
+		body             []byte
+	}
+	requests := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{
+			event:     r.Header.Get(nerdz.WebhookEventHeader),
+			signature: r.Header.Get(nerdz.WebhookSignatureHeader),
+			body:      body,
+		}
+	}))
+	defer receiver.Close()
+
+	app := webhookApp(t, "Webhook Application")
+	defer store.RemoveClient(app.ID)
+
+	if err := app.AddWebhook(&nerdz.Webhook{URL: receiver.URL, Event: "invalid"}); err == nil {
+		t.Fatal("AddWebhook should fail with an invalid event")
+	}
+	if err := app.AddWebhook(&nerdz.Webhook{URL: "/relative", Event: nerdz.WebhookEventPm}); err == nil {
+		t.Fatal("AddWebhook should fail with a relative URL")
+	}
+	if err := app.AddWebhook(&nerdz.Webhook{URL: receiver.URL, Event: nerdz.WebhookEventPm}); err == nil {
+		t.Fatal("AddWebhook should fail with a loopback URL")
+	}
+	if err := app.AddWebhook(&nerdz.Webhook{URL: "http://169.254.169.254/latest/meta-data/", Event: nerdz.WebhookEventPm}); err == nil {
+		t.Fatal("AddWebhook should fail with a link-local URL")
+	}
+
+	// the receiver is a local test server
+	nerdz.AllowPrivateWebhooks(true)
+	defer nerdz.AllowPrivateWebhooks(false)
+
+	hook := nerdz.Webhook{URL: receiver.URL, Event: nerdz.WebhookEventPm}
+	if err := app.AddWebhook(&hook); err != nil {
+		t.Fatalf("AddWebhook should work but got: %s", err.Error())
+	}
+
+	pm := nerdz.Pm{Message: "Webhooks are cool", To: me.ID()}
+	if err := other.Add(&pm); err != nil {
+		t.Fatalf("No errors should occur while adding a new pm, but got %v", err)
+	}
+	defer other.Delete(&pm)
+	nerdz.WaitWebhookTriggers()
+
+	if attempted := nerdz.DeliverPendingWebhooks(); attempted != 1 {
+		t.Fatalf("Expected 1 attempted delivery, got %d", attempted)
+	}
+
+	request := <-requests
+	if request.event != nerdz.WebhookEventPm {
+		t.Errorf("Expected event %s, got %s", nerdz.WebhookEventPm, request.event)
+	}
+	if expected := nerdz.SignWebhookPayload(app.Secret, request.body); request.signature != expected {
+		t.Errorf("Expected signature %s, got %s", expected, request.signature)
+	}
+
+	var payload nerdz.WebhookPayload
+	if err := json.Unmarshal(request.body, &payload); err != nil {
+		t.Fatalf("Invalid payload: %s", err.Error())
+	}
+	if payload.User != me.ID() {
+		t.Errorf("Expected payload for user %d, got %d", me.ID(), payload.User)
+	}
+
+	deliveries := hook.Deliveries(1)
+	if len(deliveries) != 1 || deliveries[0].Status != nerdz.WebhookDeliveryDelivered {
+		t.Fatalf("Expected a delivered delivery, got %+v", deliveries)
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	app := webhookApp(t, "Broken Webhook Application")
+	defer store.RemoveClient(app.ID)
+
+	nerdz.AllowPrivateWebhooks(true)
+	defer nerdz.AllowPrivateWebhooks(false)
+
+	hook := nerdz.Webhook{URL: receiver.URL, Event: nerdz.WebhookEventPm}
+	if err := app.AddWebhook(&hook); err != nil {
+		t.Fatalf("AddWebhook should work but got: %s", err.Error())
+	}
+
+	pm := nerdz.Pm{Message: "Nobody will read this", To: me.ID()}
+	if err := other.Add(&pm); err != nil {
+		t.Fatalf("No errors should occur while adding a new pm, but got %v", err)
+	}
+	defer other.Delete(&pm)
+	nerdz.WaitWebhookTriggers()
+
+	for i := 0; i < 8; i++ {
+		// make the retry due
+		if err := nerdz.Db().Exec("UPDATE oauth2_webhook_deliveries SET next_attempt_at = next_attempt_at - interval '1 day' WHERE webhook_id = ?", hook.ID); err != nil {
+			t.Fatalf("Unable to schedule the retry: %s", err.Error())
+		}
+		nerdz.DeliverPendingWebhooks()
+	}
+
+	deliveries := hook.Deliveries(1)
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+	}
+	if deliveries[0].Status != nerdz.WebhookDeliveryDead {
+		t.Errorf("Expected a dead delivery, got status %s", deliveries[0].Status)
+	}
+	if deliveries[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, deliveries[0].StatusCode)
+	}
+
+	var deadLetters []nerdz.WebhookDeadLetter
+	if err := nerdz.Db().Model(nerdz.WebhookDeadLetter{}).Where(&nerdz.WebhookDeadLetter{DeliveryID: deliveries[0].ID}).Scan(&deadLetters); err != nil || len(deadLetters) != 1 {
+		t.Errorf("Expected 1 dead letter, got %d (%v)", len(deadLetters), err)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package interest
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// Users handles the request and returns the users that share the interest with the current user
+func Users() echo.HandlerFunc {
+
+	// swagger:route GET /interests/{value}/users interests users GetInterestUsers
+	//
+	// Shows the users that share the interest with the current user, the most recently registered first.
+	// The users in the blacklist of the current user, or that put the current user in their blacklist, are not shown.
+	//
+	// You can paginate the request via the n and older query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:read", c) {
+			return rest.InvalidScopeResponse("profile:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("usersOptions").(*nerdz.UsersOptions)
+		users := me.InterestedUsers(rest.PathParam("value", c), *options)
+		return rest.SelectFields(rest.GetUsersInfo(users), c)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package me
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+	"github.com/nerdzeu/nerdz-api/rest/user"
+)
+
+// Drafts handles the request and returns the drafts and the scheduled posts of the current user
+func Drafts() echo.HandlerFunc {
+
+	// swagger:route GET /me/drafts me post drafts GetMeDrafts
+	//
+	// Shows the drafts and the scheduled posts of the current user on every board, from the last updated.
+	// The scheduled posts whose publication failed are back to the drafts, with the error
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("messages:read", c) {
+			return rest.InvalidScopeResponse("messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		var draftsTO []*nerdz.DraftTO
+		for _, d := range me.Drafts() {
+			draft := d
+			draftsTO = append(draftsTO, draft.GetTO(me))
+		}
+		return rest.SelectFields(draftsTO, c)
+	}
+}
+
+// NewDraft handles the request and saves a new draft, or a new scheduled post, on the current user board
+func NewDraft() echo.HandlerFunc {
+
+	// swagger:route POST /me/drafts me post drafts NewMeDraft
+	//
+	// Saves a new draft of a post on the current user board. When publishAt is set, the post is scheduled
+	// and published at that time with the same validation and notifications of a new post
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.NewDraft()(c)
+	}
+}
+
+// Draft handles the request and returns the draft
+func Draft() echo.HandlerFunc {
+
+	// swagger:route GET /me/drafts/{draft} me post drafts GetMeDraft
+	//
+	// Shows the draft, or the scheduled post
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("messages:read", c) {
+			return rest.InvalidScopeResponse("messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		return rest.SelectFields(c.Get("draft").(*nerdz.Draft).GetTO(me), c)
+	}
+}
+
+// EditDraft handles the request and updates the draft
+func EditDraft() echo.HandlerFunc {
+
+	// swagger:route PUT /me/drafts/{draft} me post drafts EditMeDraft
+	//
+	// Updates the message, the language and the publication time of the draft.
+	// Setting publishAt schedules the draft, omitting it turns a scheduled post back into a draft
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("messages:write", c) {
+			return rest.InvalidScopeResponse("messages:write", c)
+		}
+
+		message := rest.NewDraft{}
+		if err := c.Bind(&message); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		draft := c.Get("draft").(*nerdz.Draft)
+		draft.Message = message.Message
+		draft.Lang = message.Lang
+		draft.PublishAt = sql.NullTime{}
+		if message.PublishAt != 0 {
+			draft.PublishAt = sql.NullTime{Time: time.Unix(message.PublishAt, 0), Valid: true}
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.UpdateDraft(draft); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(draft.GetTO(me), c)
+	}
+}
+
+// DeleteDraft handles the request and deletes the draft
+func DeleteDraft() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/drafts/{draft} me post drafts DeleteMeDraft
+	//
+	// Deletes the draft, or the scheduled post before its publication
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("messages:write", c) {
+			return rest.InvalidScopeResponse("messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.DeleteDraft(c.Get("draft").(*nerdz.Draft)); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		errstr := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
+
+// PublishDraft handles the request and publishes the draft now
+func PublishDraft() echo.HandlerFunc {
+
+	// swagger:route POST /me/drafts/{draft}/publish me post drafts PublishMeDraft
+	//
+	// Publishes the draft, or the scheduled post, now: the draft is deleted and the new post is returned
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("messages:write", c) {
+			return rest.InvalidScopeResponse("messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post, err := me.PublishDraft(c.Get("draft").(*nerdz.Draft))
+		if err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		switch post := post.(type) {
+		case *nerdz.UserPost:
+			return rest.SelectFields(post.GetTO(me), c)
+		case *nerdz.ProjectPost:
+			return rest.SelectFields(post.GetTO(me), c)
+		}
+		return nil
+	}
+}
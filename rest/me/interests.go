@@ -0,0 +1,153 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package me
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// Interests handles the request and returns the interests of the current user
+func Interests() echo.HandlerFunc {
+
+	// swagger:route GET /me/interests me info interests GetMeInterests
+	//
+	// Shows the interests of the current user
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:read", c) {
+			return rest.InvalidScopeResponse("profile:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		return rest.SelectFields(me.Interests(), c)
+	}
+}
+
+// NewInterest handles the request and adds the interest to the current user interests
+func NewInterest() echo.HandlerFunc {
+
+	// swagger:route POST /me/interests me info interests NewMeInterest
+	//
+	// Adds the interest to the current user interests and returns the updated interests
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:write", c) {
+			return rest.InvalidScopeResponse("profile:write", c)
+		}
+
+		body := rest.NewInterest{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.AddInterest(&nerdz.Interest{Value: strings.TrimSpace(body.Value)}); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: "Unable to add the interest",
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(me.Interests(), c)
+	}
+}
+
+// DeleteInterest handles the request and removes the interest from the current user interests
+func DeleteInterest() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/interests/{value} me info interests DeleteMeInterest
+	//
+	// Removes the interest from the current user interests and returns the updated interests
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:write", c) {
+			return rest.InvalidScopeResponse("profile:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.DeleteInterest(&nerdz.Interest{From: me.ID(), Value: rest.PathParam("value", c)}); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: "Unable to remove the interest",
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(me.Interests(), c)
+	}
+}
@@ -20,6 +20,7 @@ package me
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
@@ -243,6 +244,219 @@ func PostComment() echo.HandlerFunc {
 	}
 }
 
+// PostRevisions handles the request and returns the revisions of the post
+func PostRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /me/posts/{pid}/revisions me post revisions GetMePostRevisions
+	//
+	// Shows the revisions of the post, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.PostRevisions()(c)
+	}
+}
+
+// Pinned handles the request and returns the posts pinned to the top of the current user board
+func Pinned() echo.HandlerFunc {
+
+	// swagger:route GET /me/pinned me posts GetMePinnedPosts
+	//
+	// List the posts pinned to the top of the current user board, from the newest
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.Pinned()(c)
+	}
+}
+
+// NewPostPin handles the request and pins the post to the top of the current user board
+func NewPostPin() echo.HandlerFunc {
+
+	// swagger:route POST /me/posts/{pid}/pin me post pin NewMePostPin
+	//
+	// Pins the current post to the top of the current user board.
+	// A board has at most 3 pinned posts
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.NewPostPin()(c)
+	}
+}
+
+// DeletePostPin handles the request and removes the post from the top of the current user board
+func DeletePostPin() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/posts/{pid}/pin me post pin DeleteMePostPin
+	//
+	// Removes the current post from the top of the current user board
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.DeletePostPin()(c)
+	}
+}
+
+// NewPostClosure handles the request and closes the post
+func NewPostClosure() echo.HandlerFunc {
+
+	// swagger:route POST /me/posts/{pid}/close me post close NewMePostClosure
+	//
+	// Closes the current post
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.NewPostClosure()(c)
+	}
+}
+
+// DeletePostClosure handles the request and reopens the closed post
+func DeletePostClosure() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/posts/{pid}/close me post close DeleteMePostClosure
+	//
+	// Reopens the current post
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.DeletePostClosure()(c)
+	}
+}
+
+// PostSubscription handles the request and returns the subscription of the current user to the comments of the post
+func PostSubscription() echo.HandlerFunc {
+
+	// swagger:route GET /me/posts/{pid}/subscription me post subscription GetMePostSubscription
+	//
+	// Shows if the current user receives the comment notifications of the post, and why
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.PostSubscription()(c)
+	}
+}
+
+// NewPostSubscription handles the request and subscribes the current user to the comments of the post
+func NewPostSubscription() echo.HandlerFunc {
+
+	// swagger:route PUT /me/posts/{pid}/subscription me post subscription NewMePostSubscription
+	//
+	// Subscribes the current user to the comments of the post
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.NewPostSubscription()(c)
+	}
+}
+
+// DeletePostSubscription handles the request and mutes the comment notifications of the post
+func DeletePostSubscription() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/posts/{pid}/subscription me post subscription DeleteMePostSubscription
+	//
+	// Mutes the comment notifications of the post, without unlurking it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.DeletePostSubscription()(c)
+	}
+}
+
+// PostCommentRevisions handles the request and returns the revisions of the comment
+func PostCommentRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /me/posts/{pid}/comments/{cid}/revisions me post comment revisions GetMePostCommentRevisions
+	//
+	// Shows the revisions of the comment, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_comments:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return user.PostCommentRevisions()(c)
+	}
+}
+
 // NewPostComment handles the request and creates a new post
 func NewPostComment() echo.HandlerFunc {
 
@@ -290,6 +504,263 @@ func Info() echo.HandlerFunc {
 	}
 }
 
+// UpdateInfo handles the request and updates the information of the current user
+func UpdateInfo() echo.HandlerFunc {
+
+	// swagger:route PATCH /me me info UpdateMeInfo
+	//
+	// Updates the name, surname, timezone, language, board language and online status visibility
+	// of the current user. Only the fields present in the request are updated
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:write
+	//
+	//	Responses:
+	//		default: Me
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:write", c) {
+			return rest.InvalidScopeResponse("profile:write", c)
+		}
+
+		body := rest.InfoUpdate{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		update := *me
+		if body.Name != nil {
+			update.Name = *body.Name
+		}
+		if body.Surname != nil {
+			update.Surname = *body.Surname
+		}
+		if body.Timezone != nil {
+			update.Timezone = *body.Timezone
+		}
+		if body.Lang != nil {
+			update.Lang = *body.Lang
+		}
+		if body.BoardLang != nil {
+			update.BoardLang = *body.BoardLang
+		}
+		if body.Viewonline != nil {
+			update.Viewonline = *body.Viewonline
+		}
+
+		if err := me.UpdateInfo(&update); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(rest.GetUserInfo(me), c)
+	}
+}
+
+// Board handles the request and returns the board settings of the current user
+func Board() echo.HandlerFunc {
+
+	// swagger:route GET /me/board me info GetMeBoard
+	//
+	// Shows the board settings of the current user: closed, private, whitelist-only and the whitelist
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:read", c) {
+			return rest.InvalidScopeResponse("profile:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		return rest.SelectFields(me.BoardSettings().GetTO(me), c)
+	}
+}
+
+// UpdateBoard handles the request and updates the board settings of the current user
+func UpdateBoard() echo.HandlerFunc {
+
+	// swagger:route PATCH /me/board me info UpdateMeBoard
+	//
+	// Updates the board settings of the current user. Only the fields present in the request are updated.
+	// A closed board accepts posts only by the whitelisted users, a private board is visible
+	// only by the whitelisted users, a whitelist-only board accepts posts and comments only by the whitelisted users
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:write", c) {
+			return rest.InvalidScopeResponse("profile:write", c)
+		}
+
+		body := rest.BoardUpdate{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		settings := me.BoardSettings()
+		if body.Closed != nil {
+			settings.Closed = *body.Closed
+		}
+		if body.Private != nil {
+			settings.Private = *body.Private
+		}
+		if body.WhitelistOnly != nil {
+			settings.WhitelistOnly = *body.WhitelistOnly
+		}
+
+		if err := me.UpdateBoardSettings(settings); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(settings.GetTO(me), c)
+	}
+}
+
+// UpdateProfile handles the request and updates the profile of the current user
+func UpdateProfile() echo.HandlerFunc {
+
+	// swagger:route PATCH /me/profile me info UpdateMeProfile
+	//
+	// Updates the biography, quotes, website, GitHub, Telegram, template and date format
+	// of the current user. Only the fields present in the request are updated
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:write
+	//
+	//	Responses:
+	//		default: Me
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:write", c) {
+			return rest.InvalidScopeResponse("profile:write", c)
+		}
+
+		body := rest.ProfileUpdate{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		profile := me.Profile
+		if body.Biography != nil {
+			profile.Biography = *body.Biography
+		}
+		if body.Quotes != nil {
+			profile.Quotes = *body.Quotes
+		}
+		if body.Website != nil {
+			profile.Website = *body.Website
+		}
+		if body.Github != nil {
+			profile.Github = *body.Github
+		}
+		if body.Telegram != nil {
+			profile.Telegram = *body.Telegram
+		}
+		if body.Template != nil {
+			profile.Template = *body.Template
+		}
+		if body.Dateformat != nil {
+			profile.Dateformat = *body.Dateformat
+		}
+
+		if err := me.UpdateProfile(&profile); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(rest.GetUserInfo(me), c)
+	}
+}
+
 // Friends handles the request and returns the user friends
 func Friends() echo.HandlerFunc {
 
@@ -387,7 +858,76 @@ func Whitelist() echo.HandlerFunc {
 
 	// swagger:route GET /me/whitelist me info whitelist getMeWhitelist
 	//
-	// Shows the whitelist information for the current user
+	// Shows the whitelist information for the current user
+	//
+	// You can personalize the request via query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: MeWhitelist
+
+	return func(c echo.Context) error {
+		return user.Whitelist()(c)
+	}
+}
+
+// Whitelisting handles the request and returns the user whitelistings
+func Whitelisting() echo.HandlerFunc {
+
+	// swagger:route GET /me/whitelisting me info whitelisting getMeWhitelisted
+	//
+	// Shows the whitelisting information for the current user
+	//
+	// You can personalize the request via query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: MeWhitelisting
+
+	return func(c echo.Context) error {
+		return user.Whitelisting()(c)
+	}
+}
+
+// Blacklist handles the request and returns the user blacklist
+func Blacklist() echo.HandlerFunc {
+
+	// swagger:route GET /me/blacklist me info blacklist getMeBlacklist
+	//
+	// Shows the blacklist information for the current user
+	//
+	// You can personalize the request via query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: MeBlacklist
+
+	return func(c echo.Context) error {
+		return user.Blacklist()(c)
+	}
+}
+
+// Blacklisting handles the request and returns the user blacklistings
+func Blacklisting() echo.HandlerFunc {
+
+	// swagger:route GET /me/blacklisting me info blacklisting getMeBlacklisting
+	//
+	// Shows the blacklisting information for the current user
 	//
 	// You can personalize the request via query string parameters
 	//
@@ -398,19 +938,19 @@ func Whitelist() echo.HandlerFunc {
 	//		oauth: profile:read
 	//
 	//	Responses:
-	//		default: MeWhitelist
+	//		default: MeBlacklisting
 
 	return func(c echo.Context) error {
-		return user.Whitelist()(c)
+		return user.Blacklisting()(c)
 	}
 }
 
-// Whitelisting handles the request and returns the user whitelistings
-func Whitelisting() echo.HandlerFunc {
+// Home handles the request and returns the user home
+func Home() echo.HandlerFunc {
 
-	// swagger:route GET /me/whitelisting me info whitelisting getMeWhitelisted
+	// swagger:route GET /me/home me post home getMeHome
 	//
-	// Shows the whitelisting information for the current user
+	// Shows the homepage of the current user, mixing projects and users posts
 	//
 	// You can personalize the request via query string parameters
 	//
@@ -421,19 +961,46 @@ func Whitelisting() echo.HandlerFunc {
 	//		oauth: profile:read
 	//
 	//	Responses:
-	//		default: MeWhitelisting
+	//		default: MeHome
 
 	return func(c echo.Context) error {
-		return user.Whitelisting()(c)
+		if !rest.IsGranted("messages:read", c) {
+			return rest.InvalidScopeResponse("messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("postlistOptions").(*nerdz.PostlistOptions)
+		posts := me.Home(*options)
+
+		if posts == nil {
+			errstr := "unable to fetch home page for the specified user"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "me.Home error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var postsAPI []*nerdz.PostTO
+		for _, p := range *posts {
+			postsAPI = append(postsAPI, p.GetTO(me))
+		}
+
+		return rest.SelectFields(postsAPI, c)
 	}
 }
 
-// Blacklist handles the request and returns the user blacklist
-func Blacklist() echo.HandlerFunc {
+// Bookmarks handles the request and returns the posts bookmarked by the current user
+func Bookmarks() echo.HandlerFunc {
 
-	// swagger:route GET /me/blacklist me info blacklist getMeBlacklist
+	// swagger:route GET /me/bookmarks me post bookmarks getMeBookmarks
 	//
-	// Shows the blacklist information for the current user
+	// Shows the users and projects posts bookmarked by the current user, from the most recent.
+	// The posts no more visible by the current user are not shown
 	//
 	// You can personalize the request via query string parameters
 	//
@@ -441,22 +1008,49 @@ func Blacklist() echo.HandlerFunc {
 	//	- application/json
 	//
 	//	Security:
-	//		oauth: profile:read
+	//		oauth: messages:read
 	//
 	//	Responses:
-	//		default: MeBlacklist
+	//		default: MeHome
 
 	return func(c echo.Context) error {
-		return user.Blacklist()(c)
+		if !rest.IsGranted("messages:read", c) {
+			return rest.InvalidScopeResponse("messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("postlistOptions").(*nerdz.PostlistOptions)
+		posts := me.Bookmarks(*options)
+
+		if posts == nil {
+			errstr := "unable to fetch bookmarks for the specified user"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "me.Bookmarks error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var postsAPI []*nerdz.PostTO
+		for _, p := range *posts {
+			postsAPI = append(postsAPI, p.GetTO(me))
+		}
+
+		return rest.SelectFields(postsAPI, c)
 	}
 }
 
-// Blacklisting handles the request and returns the user blacklistings
-func Blacklisting() echo.HandlerFunc {
+// Lurks handles the request and returns the posts lurked by the current user
+func Lurks() echo.HandlerFunc {
 
-	// swagger:route GET /me/blacklisting me info blacklisting getMeBlacklisting
+	// swagger:route GET /me/lurks me post lurks getMeLurks
 	//
-	// Shows the blacklisting information for the current user
+	// Shows the users and projects posts lurked by the current user, from the most recent.
+	// The posts no more visible by the current user are not shown
 	//
 	// You can personalize the request via query string parameters
 	//
@@ -464,22 +1058,50 @@ func Blacklisting() echo.HandlerFunc {
 	//	- application/json
 	//
 	//	Security:
-	//		oauth: profile:read
+	//		oauth: messages:read
 	//
 	//	Responses:
-	//		default: MeBlacklisting
+	//		default: MeHome
 
 	return func(c echo.Context) error {
-		return user.Blacklisting()(c)
+		if !rest.IsGranted("messages:read", c) {
+			return rest.InvalidScopeResponse("messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("postlistOptions").(*nerdz.PostlistOptions)
+		posts := me.Lurks(*options)
+
+		if posts == nil {
+			errstr := "unable to fetch lurks for the specified user"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "me.Lurks error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var postsAPI []*nerdz.PostTO
+		for _, p := range *posts {
+			postsAPI = append(postsAPI, p.GetTO(me))
+		}
+
+		return rest.SelectFields(postsAPI, c)
 	}
 }
 
-// Home handles the request and returns the user home
-func Home() echo.HandlerFunc {
+// Subscriptions handles the request and returns the posts whose comment notifications are received by the current user
+func Subscriptions() echo.HandlerFunc {
 
-	// swagger:route GET /me/home me post home getMeHome
+	// swagger:route GET /me/subscriptions me post subscriptions getMeSubscriptions
 	//
-	// Shows the homepage of the current user, mixing projects and users posts
+	// Shows the users and projects posts whose comment notifications are received by the current user, from the most recent:
+	// the posts written, commented or lurked by the current user, except the muted ones.
+	// The posts no more visible by the current user are not shown
 	//
 	// You can personalize the request via query string parameters
 	//
@@ -487,7 +1109,7 @@ func Home() echo.HandlerFunc {
 	//	- application/json
 	//
 	//	Security:
-	//		oauth: profile:read
+	//		oauth: messages:read
 	//
 	//	Responses:
 	//		default: MeHome
@@ -499,13 +1121,13 @@ func Home() echo.HandlerFunc {
 
 		me := c.Get("me").(*nerdz.User)
 		options := c.Get("postlistOptions").(*nerdz.PostlistOptions)
-		posts := me.Home(*options)
+		posts := me.Subscriptions(*options)
 
 		if posts == nil {
-			errstr := "unable to fetch home page for the specified user"
+			errstr := "unable to fetch subscriptions for the specified user"
 			if err := c.JSON(http.StatusBadRequest, &rest.Response{
 				HumanMessage: errstr,
-				Message:      "me.Home error",
+				Message:      "me.Subscriptions error",
 				Status:       http.StatusBadRequest,
 				Success:      false,
 			}); err != nil {
@@ -680,6 +1302,144 @@ func DeleteConversation() echo.HandlerFunc {
 	}
 }
 
+// UnreadConversations handles the request and returns the number of unread pms of every conversation
+func UnreadConversations() echo.HandlerFunc {
+
+	// swagger:route GET /me/pms/unread me pms GetMePmsUnread
+	//
+	// Shows the number of unread pms received by the current user, for every conversation
+	// with unread pms. The conversation with the most recent unread pm comes first
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		unread, e := me.UnreadConversations()
+		if e != nil {
+			errstr := "unable to fetch the unread conversations for the specified user"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "me.UnreadConversations error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var unreadTO []*nerdz.UnreadConversationTO
+		for _, u := range *unread {
+			unreadTO = append(unreadTO, u.GetTO(me))
+		}
+		return rest.SelectFields(unreadTO, c)
+	}
+}
+
+// ReadConversation handles the request and marks as read the pms received from the other user
+func ReadConversation() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/{other}/read me pms ReadMePms
+	//
+	// Marks as read every pm received by the current user from the other user
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:write", c) {
+			return rest.InvalidScopeResponse("pms:write", c)
+		}
+
+		var other *nerdz.User
+		var err error
+		if other, err = rest.User("other", c); err != nil {
+			return err
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err = me.ReadConversation(other.ID()); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
+
+// ReadPm handles the request and marks as read the pm
+func ReadPm() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/{other}/{pmid}/read me pm ReadMePm
+	//
+	// Marks as read the specified pm, received by the current user from the other user
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: MePmsOtherPmid
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:write", c) {
+			return rest.InvalidScopeResponse("pms:write", c)
+		}
+
+		pm := c.Get("pm").(*nerdz.Pm)
+		me := c.Get("me").(*nerdz.User)
+		if err := me.ReadPm(pm); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(pm.GetTO(me), c)
+	}
+}
+
 // Pm handles the request and returns the specified Private Message
 func Pm() echo.HandlerFunc {
 
@@ -709,6 +1469,43 @@ func Pm() echo.HandlerFunc {
 	}
 }
 
+// PmRevisions handles the request and returns the revisions of the specified Private Message
+func PmRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /me/pms/{other}/{pmid}/revisions me pms revisions GetMePmRevisions
+	//
+	// Shows the revisions of the pm, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+		diff, _ := strconv.ParseBool(c.QueryParam("diff"))
+		revisions := c.Get("pm").(*nerdz.Pm).History()
+		me := c.Get("me").(*nerdz.User)
+
+		var revisionsTO []*nerdz.PmRevisionTO
+		for i, revision := range revisions {
+			revisionTO := revision.GetTO(me)
+			if diff && i > 0 {
+				revisionTO.Diff = nerdz.RevisionDiff(revisions[i-1].Message, revision.Message, int(revision.RevNo))
+			}
+			revisionsTO = append(revisionsTO, revisionTO)
+		}
+		return rest.SelectFields(revisionsTO, c)
+	}
+}
+
 // NewPm handles the request and creates a new pm
 func NewPm() echo.HandlerFunc {
 
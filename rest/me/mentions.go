@@ -0,0 +1,123 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package me
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// Mentions handles the request and returns the mentions of the current user
+func Mentions() echo.HandlerFunc {
+
+	// swagger:route GET /me/mentions me mentions getMeMentions
+	//
+	// Shows the mentions of the current user in the posts and in the comments, from the most recent,
+	// with the post in which the user has been mentioned
+	//
+	// You can personalize the request via query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: notifications:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("notifications:read", c) {
+			return rest.InvalidScopeResponse("notifications:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("mentionsOptions").(*nerdz.MentionsOptions)
+		mentions, err := me.Mentions(*options)
+		if err != nil {
+			errstr := "unable to fetch mentions for the specified user"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "me.Mentions error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var mentionsTO []*nerdz.MentionTO
+		for _, m := range *mentions {
+			mentionsTO = append(mentionsTO, m.GetTO(me))
+		}
+		return rest.SelectFields(mentionsTO, c)
+	}
+}
+
+// ReadMentions handles the request and marks as notified every mention of the current user
+func ReadMentions() echo.HandlerFunc {
+
+	// swagger:route POST /me/mentions/read me mentions ReadMeMentions
+	//
+	// Marks as notified every mention of the current user
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: notifications:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("notifications:write", c) {
+			return rest.InvalidScopeResponse("notifications:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.ReadMentions(); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
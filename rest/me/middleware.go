@@ -18,7 +18,14 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package me
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
 	"github.com/nerdzeu/nerdz-api/rest/user"
 )
 
@@ -53,3 +60,202 @@ func SetComment() echo.MiddlewareFunc {
 func SetPm() echo.MiddlewareFunc {
 	return user.SetPm()
 }
+
+// SetApp is the middleware that checks if the required OAuth2 application exists
+// and if the current user is its owner. If so, set the "app" = *OAuth2Client in the current context
+func SetApp() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			var e error
+			var appID uint64
+			if appID, e = strconv.ParseUint(c.Param("app"), 10, 64); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Invalid application identifier specified",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			var app *nerdz.OAuth2Client
+			if app, e = nerdz.NewOAuth2Client(appID); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Required application does not exists",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			me := c.Get("me").(*nerdz.User)
+			if app.UserID != me.ID() {
+				message := "You're not the owner of the required application"
+				if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusUnauthorized,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return echo.ErrUnauthorized
+			}
+
+			c.Set("app", app)
+			return next(c)
+		})
+	}
+}
+
+// SetWebhook is the middleware that checks if the required webhook exists and
+// if it's registered by the application in the context.
+// If so, set the "webhook" = *Webhook in the current context
+func SetWebhook() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			var e error
+			var webhookID uint64
+			if webhookID, e = strconv.ParseUint(c.Param("wid"), 10, 64); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Invalid webhook identifier specified",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			app := c.Get("app").(*nerdz.OAuth2Client)
+			var webhook *nerdz.Webhook
+			if webhook, e = nerdz.NewWebhook(webhookID); e == nil && webhook.ClientID != app.ID {
+				e = errors.New("the webhook is not registered by the application")
+			}
+			if e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Required webhook does not exists",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			c.Set("webhook", webhook)
+			return next(c)
+		})
+	}
+}
+
+// SetPmGroup is the middleware that checks if the required group conversation exists and
+// if the current user participates in it. If so, set the "pmGroup" = *PmGroup in the current context
+func SetPmGroup() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			var e error
+			var groupID uint64
+			if groupID, e = strconv.ParseUint(c.Param("group"), 10, 64); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Invalid group conversation identifier specified",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			var group *nerdz.PmGroup
+			if group, e = nerdz.NewPmGroup(groupID); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Required group conversation does not exists",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			me := c.Get("me").(*nerdz.User)
+			if !group.HasMember(me) {
+				message := "You don't participate in the required group conversation"
+				if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusUnauthorized,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return echo.ErrUnauthorized
+			}
+
+			c.Set("pmGroup", group)
+			return next(c)
+		})
+	}
+}
+
+// SetDraft is the middleware that checks if the required draft exists and
+// if the current user owns it. If so, set the "draft" = *Draft in the current context
+func SetDraft() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			var e error
+			var draftID uint64
+			if draftID, e = strconv.ParseUint(c.Param("draft"), 10, 64); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Invalid draft identifier specified",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			var draft *nerdz.Draft
+			if draft, e = nerdz.NewDraft(draftID); e != nil {
+				if err := c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: "Required draft does not exists",
+					Message:      e.Error(),
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return e
+			}
+
+			me := c.Get("me").(*nerdz.User)
+			if draft.From != me.ID() {
+				message := "You don't own the required draft"
+				if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusUnauthorized,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return echo.ErrUnauthorized
+			}
+
+			c.Set("draft", draft)
+			return next(c)
+		})
+	}
+}
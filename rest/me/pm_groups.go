@@ -0,0 +1,441 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package me
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// PmGroups handles the request and returns the group conversations of the current user
+func PmGroups() echo.HandlerFunc {
+
+	// swagger:route GET /me/pms/groups me pms groups GetMePmGroups
+	//
+	// Shows the group conversations of the current user, with their participants
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		var groupsTO []*nerdz.PmGroupTO
+		for _, group := range me.PmGroups() {
+			groupsTO = append(groupsTO, group.GetTO(me))
+		}
+		return rest.SelectFields(groupsTO, c)
+	}
+}
+
+// NewPmGroup handles the request and creates a group conversation of the current user
+func NewPmGroup() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/groups me pms groups NewMePmGroup
+	//
+	// Creates a group conversation with the specified participants. The current user is its admin.
+	// The participants must not have blacklisted each other
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:write", c) {
+			return rest.InvalidScopeResponse("pms:write", c)
+		}
+
+		body := rest.NewPmGroup{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		group, err := me.CreatePmGroup(body.Name, body.Members)
+		if err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: "Unable to create the group conversation: " + errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(group.GetTO(me), c)
+	}
+}
+
+// PmGroup handles the request and returns the group conversation
+func PmGroup() echo.HandlerFunc {
+
+	// swagger:route GET /me/pms/groups/{group} me pms groups GetMePmGroup
+	//
+	// Shows the group conversation and its participants
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		group := c.Get("pmGroup").(*nerdz.PmGroup)
+		return rest.SelectFields(group.GetTO(me), c)
+	}
+}
+
+// PmGroupMessages handles the request and returns the messages of the group conversation
+func PmGroupMessages() echo.HandlerFunc {
+
+	// swagger:route GET /me/pms/groups/{group}/messages me pms groups GetMePmGroupMessages
+	//
+	// Returns the messages of the group conversation
+	//
+	// You can personalize the request via query string parameters
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+
+		group := c.Get("pmGroup").(*nerdz.PmGroup)
+		options := c.Get("pmsOptions").(*nerdz.PmsOptions)
+		messages, err := group.Messages(*options)
+		if err != nil {
+			errstr := "unable to fetch the messages of the group conversation"
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				HumanMessage: errstr,
+				Message:      "group.Messages error",
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var messagesTO []*nerdz.PmGroupMessageTO
+		for _, m := range *messages {
+			message := m
+			messagesTO = append(messagesTO, message.GetTO())
+		}
+		return rest.SelectFields(messagesTO, c)
+	}
+}
+
+// NewPmGroupMessage handles the request and sends a message to the group conversation
+func NewPmGroupMessage() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/groups/{group}/messages me pms groups NewMePmGroupMessage
+	//
+	// Sends a message from the current user to the group conversation
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:write", c) {
+			return rest.InvalidScopeResponse("pms:write", c)
+		}
+
+		body := rest.NewMessage{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		group := c.Get("pmGroup").(*nerdz.PmGroup)
+		message := nerdz.PmGroupMessage{GroupID: group.ID, Message: body.Message, Lang: body.Lang}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.Add(&message); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(message.GetTO(me), c)
+	}
+}
+
+// ReadPmGroup handles the request and marks as read the messages of the group conversation
+func ReadPmGroup() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/groups/{group}/read me pms groups ReadMePmGroup
+	//
+	// Marks as read every message of the group conversation
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:write", c) {
+			return rest.InvalidScopeResponse("pms:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		group := c.Get("pmGroup").(*nerdz.PmGroup)
+		if err := me.ReadPmGroup(group); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
+
+// AddPmGroupMember handles the request and adds the target user to the group conversation
+func AddPmGroupMember() echo.HandlerFunc {
+
+	// swagger:route POST /me/pms/groups/{group}/members/{target} me pms groups AddMePmGroupMember
+	//
+	// Adds the target user to the group conversation and returns the updated group conversation.
+	// Only an admin can add participants, and the target user must not have blacklisted,
+	// or been blacklisted by, a participant
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return pmGroupAction(c, func(me *nerdz.User, group *nerdz.PmGroup, target *nerdz.User) error {
+			return me.AddPmGroupMember(group, target)
+		})
+	}
+}
+
+// RemovePmGroupMember handles the request and removes the target user from the group conversation
+func RemovePmGroupMember() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/pms/groups/{group}/members/{target} me pms groups RemoveMePmGroupMember
+	//
+	// Removes the target user from the group conversation and returns the updated group conversation.
+	// Only an admin can remove other participants: when the target is the current user, the current user leaves.
+	// When the last admin leaves, the oldest participant becomes admin
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return pmGroupAction(c, func(me *nerdz.User, group *nerdz.PmGroup, target *nerdz.User) error {
+			return me.RemovePmGroupMember(group, target)
+		})
+	}
+}
+
+// GrantPmGroupAdmin handles the request and grants the admin role to the target participant
+func GrantPmGroupAdmin() echo.HandlerFunc {
+
+	// swagger:route PUT /me/pms/groups/{group}/admins/{target} me pms groups GrantMePmGroupAdmin
+	//
+	// Grants the admin role to the target participant and returns the updated group conversation.
+	// Only an admin can change the roles
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return pmGroupAction(c, func(me *nerdz.User, group *nerdz.PmGroup, target *nerdz.User) error {
+			return me.SetPmGroupAdmin(group, target, true)
+		})
+	}
+}
+
+// RevokePmGroupAdmin handles the request and revokes the admin role of the target participant
+func RevokePmGroupAdmin() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/pms/groups/{group}/admins/{target} me pms groups RevokeMePmGroupAdmin
+	//
+	// Revokes the admin role of the target participant and returns the updated group conversation.
+	// Only an admin can change the roles, and a group conversation always has an admin
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: pms:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return pmGroupAction(c, func(me *nerdz.User, group *nerdz.PmGroup, target *nerdz.User) error {
+			return me.SetPmGroupAdmin(group, target, false)
+		})
+	}
+}
+
+// pmGroupAction executes the action of the current user on the target participant of the group conversation
+// and returns the updated group conversation. When the current user is no more a participant, it returns a success
+func pmGroupAction(c echo.Context, action func(me *nerdz.User, group *nerdz.PmGroup, target *nerdz.User) error) error {
+	if !rest.IsGranted("pms:write", c) {
+		return rest.InvalidScopeResponse("pms:write", c)
+	}
+
+	target, err := rest.User("target", c)
+	if err != nil {
+		return err
+	}
+
+	me := c.Get("me").(*nerdz.User)
+	group := c.Get("pmGroup").(*nerdz.PmGroup)
+	if err = action(me, group, target); err != nil {
+		errstr := err.Error()
+		if err := c.JSON(http.StatusBadRequest, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusBadRequest,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+
+	if !group.HasMember(me) {
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+	return rest.SelectFields(group.GetTO(me), c)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package me
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// Webhooks handles the request and returns the webhooks registered by the application
+func Webhooks() echo.HandlerFunc {
+
+	// swagger:route GET /me/apps/{app}/webhooks me apps webhooks GetMeAppWebhooks
+	//
+	// Shows the webhooks registered by the application of the current user
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: apps:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("apps:read", c) {
+			return rest.InvalidScopeResponse("apps:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		app := c.Get("app").(*nerdz.OAuth2Client)
+		var webhooksTO []*nerdz.WebhookTO
+		for _, webhook := range app.Webhooks() {
+			webhooksTO = append(webhooksTO, webhook.GetTO(me))
+		}
+		return rest.SelectFields(webhooksTO, c)
+	}
+}
+
+// NewWebhook handles the request and registers a new webhook for the application
+func NewWebhook() echo.HandlerFunc {
+
+	// swagger:route POST /me/apps/{app}/webhooks me apps webhooks NewMeAppWebhook
+	//
+	// Registers a new webhook for the application of the current user.
+	// The events are delivered with a POST request, whose body is signed
+	// with the secret of the application (X-Nerdz-Signature header)
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: apps:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("apps:write", c) {
+			return rest.InvalidScopeResponse("apps:write", c)
+		}
+
+		body := rest.NewWebhook{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		app := c.Get("app").(*nerdz.OAuth2Client)
+		webhook := nerdz.Webhook{URL: body.URL, Event: body.Event}
+		if err := app.AddWebhook(&webhook); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		return rest.SelectFields(webhook.GetTO(me), c)
+	}
+}
+
+// DeleteWebhook handles the request and deletes the webhook of the application
+func DeleteWebhook() echo.HandlerFunc {
+
+	// swagger:route DELETE /me/apps/{app}/webhooks/{wid} me apps webhooks DeleteMeAppWebhook
+	//
+	// Deletes the webhook of the application of the current user, together with its deliveries
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: apps:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("apps:write", c) {
+			return rest.InvalidScopeResponse("apps:write", c)
+		}
+
+		app := c.Get("app").(*nerdz.OAuth2Client)
+		webhook := c.Get("webhook").(*nerdz.Webhook)
+		if err := app.DeleteWebhook(webhook); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
+
+// WebhookDeliveries handles the request and returns the delivery log of the webhook
+func WebhookDeliveries() echo.HandlerFunc {
+
+	// swagger:route GET /me/apps/{app}/webhooks/{wid}/deliveries me apps webhooks GetMeAppWebhookDeliveries
+	//
+	// Shows the last deliveries to the webhook of the application of the current user, the most recent first.
+	// The deliveries that failed too many times have the "dead" status.
+	// The number of deliveries can be set with the n query string parameter
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: apps:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("apps:read", c) {
+			return rest.InvalidScopeResponse("apps:read", c)
+		}
+
+		n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+		me := c.Get("me").(*nerdz.User)
+		webhook := c.Get("webhook").(*nerdz.Webhook)
+		var deliveriesTO []*nerdz.WebhookDeliveryTO
+		for _, delivery := range webhook.Deliveries(nerdz.AtMostWebhookDeliveries(n)) {
+			deliveriesTO = append(deliveriesTO, delivery.GetTO(me))
+		}
+		return rest.SelectFields(deliveriesTO, c)
+	}
+}
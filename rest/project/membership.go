@@ -0,0 +1,345 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package project
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// Join handles the request and makes the current user join the project
+func Join() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/join project members JoinProject
+	//
+	// Makes the current user a member of the project, if the project is open or the current user has been invited.
+	// Otherwise a join request is sent to the owner of the project
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, _ *nerdz.User) error {
+			return me.JoinProject(project)
+		})
+	}
+}
+
+// Leave handles the request and removes the current user from the project
+func Leave() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/leave project members LeaveProject
+	//
+	// Removes the current user from the members of the project, or deletes the pending invitation
+	// or join request of the current user. The owner can't leave the project
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, _ *nerdz.User) error {
+			return me.LeaveProject(project)
+		})
+	}
+}
+
+// Kick handles the request and removes the target user from the members of the project
+func Kick() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id}/members/{target} project members KickProjectMember
+	//
+	// Removes the target user from the members of the project. Only the owner and the admins can remove the members, with a lower role
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error {
+			return me.KickFromProject(project, target)
+		})
+	}
+}
+
+// Invites handles the request and returns the pending invitations to the project
+func Invites() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/invites project members GetProjectInvites
+	//
+	// Shows the pending invitations to the project, from the oldest. Only the owner and the members can see them
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipRequests(c, nerdz.ProjectInvite, func(me *nerdz.User, project *nerdz.Project) bool {
+			return project.IsOwnedBy(me) || project.HasMember(me)
+		})
+	}
+}
+
+// Invite handles the request and invites the target user to join the project
+func Invite() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/invites/{target} project members InviteToProject
+	//
+	// Invites the target user to join the project. The owner, the admins and the moderators can invite.
+	// If the target user already sent a join request, the target user becomes a member
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error {
+			return me.InviteToProject(project, target)
+		})
+	}
+}
+
+// JoinRequests handles the request and returns the pending join requests of the project
+func JoinRequests() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/requests project members GetProjectJoinRequests
+	//
+	// Shows the pending join requests of the project, from the oldest. Only the owner and the admins can see them
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipRequests(c, nerdz.ProjectJoinRequest, func(me *nerdz.User, project *nerdz.Project) bool {
+			return project.HasPermission(me, nerdz.ProjectPermissionManageMembers)
+		})
+	}
+}
+
+// ApproveJoinRequest handles the request and approves the join request of the target user
+func ApproveJoinRequest() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/requests/{target} project members ApproveProjectJoinRequest
+	//
+	// Approves the join request of the target user, that becomes a member. Only the owner and the admins can approve the join requests
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error {
+			return me.ApproveProjectRequest(project, target)
+		})
+	}
+}
+
+// DenyJoinRequest handles the request and denies the join request of the target user
+func DenyJoinRequest() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id}/requests/{target} project members DenyProjectJoinRequest
+	//
+	// Denies the join request of the target user. Only the owner and the admins can deny the join requests
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error {
+			return me.DenyProjectRequest(project, target)
+		})
+	}
+}
+
+// Roles handles the request and returns the members of the project with their roles
+func Roles() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/roles project members GetProjectRoles
+	//
+	// Shows the members of the project with their roles (admin, moderator or member), in order of joining
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:read", c) {
+			return rest.InvalidScopeResponse("projects:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		var membersTO []*nerdz.ProjectMemberTO
+		for _, m := range c.Get("project").(*nerdz.Project).Memberships() {
+			membersTO = append(membersTO, m.GetTO(me))
+		}
+		return rest.SelectFields(membersTO, c)
+	}
+}
+
+// SetRole handles the request and changes the role of the target member of the project
+func SetRole() echo.HandlerFunc {
+
+	// swagger:route PUT /projects/{id}/members/{target}/role project members SetProjectMemberRole
+	//
+	// Changes the role of the target member of the project. The owner and the admins can change
+	// the roles of the members with a lower role, granting them at most a lower role
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return membershipAction(c, func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error {
+			body := rest.ProjectRole{}
+			if err := c.Bind(&body); err != nil {
+				return err
+			}
+			return me.SetProjectRole(project, target, body.Role)
+		})
+	}
+}
+
+// membershipAction executes the action of the current user on the membership of the target user of the project.
+// The target is nil when the route has no target parameter
+func membershipAction(c echo.Context, action func(me *nerdz.User, project *nerdz.Project, target *nerdz.User) error) error {
+	if !rest.IsGranted("projects:write", c) {
+		return rest.InvalidScopeResponse("projects:write", c)
+	}
+
+	var target *nerdz.User
+	if c.Param("target") != "" {
+		var err error
+		if target, err = rest.User("target", c); err != nil {
+			return err
+		}
+	}
+
+	me := c.Get("me").(*nerdz.User)
+	if err := action(me, c.Get("project").(*nerdz.Project), target); err != nil {
+		errstr := err.Error()
+		if err := c.JSON(http.StatusBadRequest, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusBadRequest,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+
+	message := "success"
+	return c.JSON(http.StatusOK, &rest.Response{
+		Data:         nil,
+		HumanMessage: message,
+		Message:      message,
+		Status:       http.StatusOK,
+		Success:      true,
+	})
+}
+
+// membershipRequests returns the pending membership requests of the kind, if the current user is allowed to see them
+func membershipRequests(c echo.Context, kind string, allowed func(me *nerdz.User, project *nerdz.Project) bool) error {
+	if !rest.IsGranted("projects:read", c) {
+		return rest.InvalidScopeResponse("projects:read", c)
+	}
+
+	me := c.Get("me").(*nerdz.User)
+	project := c.Get("project").(*nerdz.Project)
+	if !allowed(me, project) {
+		errstr := "you can't see the pending " + kind + "s of the project"
+		if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusUnauthorized,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+
+	var requestsTO []*nerdz.ProjectMembershipRequestTO
+	for _, r := range project.MembershipRequests(kind) {
+		requestsTO = append(requestsTO, r.GetTO(me))
+	}
+	return rest.SelectFields(requestsTO, c)
+}
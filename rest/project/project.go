@@ -18,13 +18,19 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package project
 
 import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 	"github.com/nerdzeu/nerdz-api/nerdz"
 	"github.com/nerdzeu/nerdz-api/rest"
+	"github.com/nerdzeu/nerdz-api/utils"
 )
 
 // Posts handles the request and returns the required posts written by the specified project
@@ -160,6 +166,75 @@ func NewPost() echo.HandlerFunc {
 	}
 }
 
+// NewDraft handles the request and saves a new draft, or a new scheduled post, on the board
+func NewDraft() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/drafts project post drafts NewProjectDraft
+	//
+	// Saves a new draft of a post on the board. When publishAt is set, the post is scheduled
+	// and published at that time with the same validation and notifications of a new post
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+
+		// Read a rest.NewDraft from the body request.
+		message := rest.NewDraft{}
+		if err := c.Bind(&message); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		draft := nerdz.Draft{
+			To:      c.Get("project").(*nerdz.Project).ID(),
+			Type:    nerdz.ProjectBoardID,
+			Message: message.Message,
+			Lang:    message.Lang,
+		}
+		if message.PublishAt != 0 {
+			draft.PublishAt = sql.NullTime{Time: time.Unix(message.PublishAt, 0), Valid: true}
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.SaveDraft(&draft); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(draft.GetTO(me), c)
+	}
+}
+
 // DeletePost handles the request and deletes the specified post
 func DeletePost() echo.HandlerFunc {
 
@@ -330,6 +405,80 @@ func PostComment() echo.HandlerFunc {
 	}
 }
 
+// PostRevisions handles the request and returns the revisions of the post
+func PostRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/posts/{pid}/revisions project post revisions GetProjectPostRevisions
+	//
+	// Shows the revisions of the post, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:read", c) {
+			return rest.InvalidScopeResponse("project_messages:read", c)
+		}
+		diff, _ := strconv.ParseBool(c.QueryParam("diff"))
+		revisions := c.Get("post").(*nerdz.ProjectPost).History()
+		me := c.Get("me").(*nerdz.User)
+
+		var revisionsTO []*nerdz.ProjectPostRevisionTO
+		for i, revision := range revisions {
+			revisionTO := revision.GetTO(me)
+			if diff && i > 0 {
+				revisionTO.Diff = nerdz.RevisionDiff(revisions[i-1].Message, revision.Message, int(revision.RevNo))
+			}
+			revisionsTO = append(revisionsTO, revisionTO)
+		}
+		return rest.SelectFields(revisionsTO, c)
+	}
+}
+
+// PostCommentRevisions handles the request and returns the revisions of the comment
+func PostCommentRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/posts/{pid}/comments/{cid}/revisions project post comment revisions GetProjectPostCommentRevisions
+	//
+	// Shows the revisions of the comment, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_comments:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_comments:read", c) {
+			return rest.InvalidScopeResponse("project_comments:read", c)
+		}
+		diff, _ := strconv.ParseBool(c.QueryParam("diff"))
+		revisions := c.Get("comment").(*nerdz.ProjectPostComment).History()
+		me := c.Get("me").(*nerdz.User)
+
+		var revisionsTO []*nerdz.ProjectPostCommentRevisionTO
+		for i, revision := range revisions {
+			revisionTO := revision.GetTO(me)
+			if diff && i > 0 {
+				revisionTO.Diff = nerdz.RevisionDiff(revisions[i-1].Message, revision.Message, int(revision.RevNo))
+			}
+			revisionsTO = append(revisionsTO, revisionTO)
+		}
+		return rest.SelectFields(revisionsTO, c)
+	}
+}
+
 // NewPostComment handles the request and creates a new post
 func NewPostComment() echo.HandlerFunc {
 
@@ -1326,3 +1475,782 @@ func DeletePostUserLock() echo.HandlerFunc {
 		})
 	}
 }
+
+// Pinned handles the request and returns the posts pinned to the top of the project board
+func Pinned() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/pinned project posts GetProjectPinnedPosts
+	//
+	// List the posts pinned to the top of the project board, from the newest
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:read", c) {
+			return rest.InvalidScopeResponse("project_messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		var postsAPI []*nerdz.PostTO
+		for _, p := range *c.Get("project").(*nerdz.Project).PinnedPosts() {
+			postsAPI = append(postsAPI, p.(*nerdz.ProjectPost).GetTO(me))
+		}
+		return rest.SelectFields(postsAPI, c)
+	}
+}
+
+// NewPostPin handles the request and pins the post to the top of the board
+func NewPostPin() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/posts/{pid}/pin project post pin NewProjectPostPin
+	//
+	// Pins the current post to the top of the board. The owner, the admins and the moderators can pin.
+	// A board has at most 3 pinned posts
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.ProjectPost)
+		if err := me.Pin(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// DeletePostPin handles the request and removes the post from the top of the board
+func DeletePostPin() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id}/posts/{pid}/pin project post pin DeleteProjectPostPin
+	//
+	// Removes the current post from the top of the board
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.ProjectPost)
+		if err := me.Unpin(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// NewPostClosure handles the request and closes the post
+func NewPostClosure() echo.HandlerFunc {
+
+	// swagger:route POST /projects/{id}/posts/{pid}/close project post close NewProjectPostClosure
+	//
+	// Closes the current post: only the owners of the post can close it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.ProjectPost)
+		if err := me.Close(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// DeletePostClosure handles the request and reopens the closed post
+func DeletePostClosure() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id}/posts/{pid}/close project post close DeleteProjectPostClosure
+	//
+	// Reopens the current post: only the owners of the post can reopen it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.ProjectPost)
+		if err := me.Reopen(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// PostSubscription handles the request and returns the subscription of the current user to the comments of the post
+func PostSubscription() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/posts/{pid}/subscription project post subscription GetProjectPostSubscription
+	//
+	// Shows if the current user receives the comment notifications of the post, and why
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:read", c) {
+			return rest.InvalidScopeResponse("project_messages:read", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Subscription)
+	}
+}
+
+// NewPostSubscription handles the request and subscribes the current user to the comments of the post
+func NewPostSubscription() echo.HandlerFunc {
+
+	// swagger:route PUT /projects/{id}/posts/{pid}/subscription project post subscription NewProjectPostSubscription
+	//
+	// Subscribes the current user to the comments of the post: the post is unmuted and,
+	// if the current user is neither the author nor a commenter, the post is lurked
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Subscribe)
+	}
+}
+
+// DeletePostSubscription handles the request and mutes the comment notifications of the post
+func DeletePostSubscription() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id}/posts/{pid}/subscription project post subscription DeleteProjectPostSubscription
+	//
+	// Mutes the comment notifications of the post, without unlurking it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: project_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("project_messages:write", c) {
+			return rest.InvalidScopeResponse("project_messages:write", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Unsubscribe)
+	}
+}
+
+// postSubscription applies the action to the post and writes the resulting subscription
+func postSubscription(c echo.Context, action func(nerdz.ExistingPost) (*nerdz.PostSubscription, error)) error {
+	subscription, err := action(c.Get("post").(*nerdz.ProjectPost))
+	if err != nil {
+		errstr := err.Error()
+		if err := c.JSON(http.StatusBadRequest, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusBadRequest,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+	return rest.SelectFields(subscription.GetTO(c.Get("me").(*nerdz.User)), c)
+}
+
+// Stats handles the request and returns the statistics of the activity of the project
+func Stats() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/stats project info stats GetProjectStats
+	//
+	// Shows the number of posts, comments, votes, distinct posters and followers of the project,
+	// aggregated by day, week or month. Only the owner and the members can see them
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:read", c) {
+			return rest.InvalidScopeResponse("projects:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		project := c.Get("project").(*nerdz.Project)
+		if project.Role(me) == nerdz.ProjectRoleNone {
+			errstr := "only the owner and the members can see the statistics of the project"
+			if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusUnauthorized,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		stats, err := project.Stats(*c.Get("projectStatsOptions").(*nerdz.ProjectStatsOptions))
+		if err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(stats.GetTO(), c)
+	}
+}
+
+// FollowersExport handles the request and streams the followers of the project
+func FollowersExport() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/followers/export project info followers ExportProjectFollowers
+	//
+	// Streams the followers of the project, in order of following, as CSV (default) or NDJSON (format=ndjson).
+	// Only the roles with the permission to export can export the followers,
+	// the contact info are exported only to the owner with the contacts scope
+	//
+	//	Produces:
+	//	- text/csv
+	//	- application/x-ndjson
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return export(c, "followers", c.Get("project").(*nerdz.Project).ExportFollowers)
+	}
+}
+
+// MembersExport handles the request and streams the members of the project
+func MembersExport() echo.HandlerFunc {
+
+	// swagger:route GET /projects/{id}/members/export project info members ExportProjectMembers
+	//
+	// Streams the members of the project, in order of joining, as CSV (default) or NDJSON (format=ndjson).
+	// Only the roles with the permission to export can export the members,
+	// the contact info are exported only to the owner with the contacts scope
+	//
+	//	Produces:
+	//	- text/csv
+	//	- application/x-ndjson
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		return export(c, "members", c.Get("project").(*nerdz.Project).ExportMembers)
+	}
+}
+
+// exportFlushRows is the number of rows written before flushing the export to the client
+const exportFlushRows = 100
+
+// export streams the users exported by exportFunc in the format required by the format query parameter.
+// Once the streaming started, the errors can't be reported to the client anymore, thus they are only logged
+func export(c echo.Context, name string, exportFunc func(*nerdz.User, func(*nerdz.ProjectExportUser) error) error) error {
+	if !rest.IsGranted("projects:read", c) {
+		return rest.InvalidScopeResponse("projects:read", c)
+	}
+
+	me := c.Get("me").(*nerdz.User)
+	project := c.Get("project").(*nerdz.Project)
+	if !project.HasPermission(me, nerdz.ProjectPermissionExport) {
+		errstr := "you don't have the permission to export the " + name + " of the project"
+		if err := c.JSON(http.StatusUnauthorized, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusUnauthorized,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = nerdz.ExportCSV
+	}
+	if !utils.InSlice(format, nerdz.ExportFormats) {
+		errstr := "invalid format: " + format
+		if err := c.JSON(http.StatusBadRequest, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusBadRequest,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+	// the contact info are exported only to the owner
+	var contacts *nerdz.User
+	if project.Role(me) == nerdz.ProjectRoleOwner && rest.IsGranted("contacts:read", c) {
+		contacts = me
+	}
+
+	response := c.Response()
+	filename := strconv.FormatUint(project.ID(), 10) + "-" + name + "." + format
+	response.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	if format == nerdz.ExportCSV {
+		response.Header().Set(echo.HeaderContentType, "text/csv; charset=UTF-8")
+	} else {
+		response.Header().Set(echo.HeaderContentType, "application/x-ndjson; charset=UTF-8")
+	}
+	response.WriteHeader(http.StatusOK)
+
+	var write func(*nerdz.ProjectExportUserTO) error
+	var flush func() error
+	if format == nerdz.ExportCSV {
+		writer := csv.NewWriter(response)
+		header := []string{"id", "username", "joined"}
+		if contacts != nil {
+			header = append(header, "website", "github", "skype", "jabber", "telegram", "facebook", "twitter", "steam")
+		}
+		if err := writer.Write(header); err != nil {
+			// the response is already committed: the error can't be sent to the client
+			log.Errorf("Error while exporting the %s of project %d: %s", name, project.ID(), err.Error())
+			return nil
+		}
+		write = func(to *nerdz.ProjectExportUserTO) error {
+			record := []string{strconv.FormatUint(to.ID, 10), to.Username, to.Joined.UTC().Format(time.RFC3339)}
+			if to.Contacts != nil {
+				record = append(record, to.Contacts.Website, to.Contacts.GitHub, to.Contacts.Skype, to.Contacts.Jabber,
+					to.Contacts.Telegram, to.Contacts.Facebook, to.Contacts.Twitter, to.Contacts.Steam)
+			}
+			return writer.Write(record)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		encoder := json.NewEncoder(response)
+		write = func(to *nerdz.ProjectExportUserTO) error {
+			return encoder.Encode(to)
+		}
+		flush = func() error {
+			return nil
+		}
+	}
+
+	rows := 0
+	err := exportFunc(contacts, func(user *nerdz.ProjectExportUser) error {
+		if err := write(user.GetTO(me)); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			response.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// the response is already committed: the error can't be sent to the client
+		log.Errorf("Error while exporting the %s of project %d: %s", name, project.ID(), err.Error())
+		return nil
+	}
+	response.Flush()
+	return nil
+}
+
+// Search handles the request and returns the projects whose name matches the query
+func Search() echo.HandlerFunc {
+
+	// swagger:route GET /projects project info SearchProjects
+	//
+	// Searches the projects by name: the names starting with the query (q) come first, then the most similar names.
+	// With autocomplete=true only the names starting with the query match.
+	// With name=<name> only the project with that (case insensitive) name is returned.
+	// The invisible projects are returned only to their owner and members
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:read", c) {
+			return rest.InvalidScopeResponse("projects:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("directoryOptions").(*nerdz.DirectoryOptions)
+		return rest.SelectFields(rest.GetProjectsInfo(me.SearchProjects(*options)), c)
+	}
+}
+
+// New handles the request and creates a new project, owned by the current user
+func New() echo.HandlerFunc {
+
+	// swagger:route POST /projects project NewProject
+	//
+	// Creates a new project, owned by the current user
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:write", c) {
+			return rest.InvalidScopeResponse("projects:write", c)
+		}
+
+		body := rest.NewProject{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		project := nerdz.Project{
+			Name:        body.Name,
+			Description: body.Description,
+			Goal:        body.Goal,
+			Website:     sql.NullString{String: body.Website},
+			Photo:       sql.NullString{String: body.Photo},
+			Visible:     body.Visible,
+			Private:     body.Private,
+			Open:        body.Open,
+		}
+		me := c.Get("me").(*nerdz.User)
+		if err := me.CreateProject(&project); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		return rest.SelectFields(project.GetTO(me), c)
+	}
+}
+
+// Update handles the request and updates the project
+func Update() echo.HandlerFunc {
+
+	// swagger:route PATCH /projects/{id} project UpdateProject
+	//
+	// Updates the description, goal, website, photo, visibility, privacy and openness of the project.
+	// Only the fields present in the request are updated. Only the owner and the admins can update the project
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:write", c) {
+			return rest.InvalidScopeResponse("projects:write", c)
+		}
+
+		body := rest.ProjectUpdate{}
+		if err := c.Bind(&body); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		project := c.Get("project").(*nerdz.Project)
+		update := *project
+		if body.Description != nil {
+			update.Description = *body.Description
+		}
+		if body.Goal != nil {
+			update.Goal = *body.Goal
+		}
+		if body.Website != nil {
+			update.Website = sql.NullString{String: *body.Website}
+		}
+		if body.Photo != nil {
+			update.Photo = sql.NullString{String: *body.Photo}
+		}
+		if body.Visible != nil {
+			update.Visible = *body.Visible
+		}
+		if body.Private != nil {
+			update.Private = *body.Private
+		}
+		if body.Open != nil {
+			update.Open = *body.Open
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.UpdateProject(project, &update); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(project.GetTO(me), c)
+	}
+}
+
+// Delete handles the request and deletes the project
+func Delete() echo.HandlerFunc {
+
+	// swagger:route DELETE /projects/{id} project DeleteProject
+	//
+	// Deletes the project, with its posts. Only the owner can delete the project
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:write", c) {
+			return rest.InvalidScopeResponse("projects:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.DeleteProject(c.Get("project").(*nerdz.Project)); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		message := "success"
+		return c.JSON(http.StatusOK, &rest.Response{
+			Data:         nil,
+			HumanMessage: message,
+			Message:      message,
+			Status:       http.StatusOK,
+			Success:      true,
+		})
+	}
+}
+
+// TransferOwnership handles the request and makes the target user the owner of the project
+func TransferOwnership() echo.HandlerFunc {
+
+	// swagger:route PUT /projects/{id}/owner/{target} project TransferProject
+	//
+	// Makes the target user the owner of the project. Only the owner can transfer the project
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: projects:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("projects:write", c) {
+			return rest.InvalidScopeResponse("projects:write", c)
+		}
+
+		var target *nerdz.User
+		var err error
+		if target, err = rest.User("target", c); err != nil {
+			return err
+		}
+
+		project := c.Get("project").(*nerdz.Project)
+		me := c.Get("me").(*nerdz.User)
+		if err = me.TransferProject(project, target); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(project.Info().GetTO(), c)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"reflect"
 	"sort"
 	"strconv"
@@ -162,25 +163,35 @@ func InvalidScopeResponse(requiredScope string, c echo.Context) error {
 }
 
 // User extract "id" from the url parameter, parse it and returns
-// the User if the "me" (in the context) user is allowed to see it.
+// the User if the "me" (in the context) user is allowed to see its profile.
+// The content of its board may still be not visible: see nerdz.User.CanSee.
+// The parameter can be the numeric ID or @username: in the latter case
+// the Content-Location header refers to the numeric ID.
 // Otherwise returns an error
 func User(userID string, c echo.Context) (*nerdz.User, error) {
-	var id uint64
+	var user *nerdz.User
 	var err error
-	if id, err = strconv.ParseUint(c.Param(userID), 10, 64); err != nil {
-		if e := c.JSON(http.StatusBadRequest, &Response{
-			HumanMessage: "Invalid user identifier specified",
-			Message:      err.Error(),
-			Status:       http.StatusBadRequest,
-			Success:      false,
-		}); e != nil {
-			log.Errorf("Error while writing response: %s", e.Error())
+	if value := PathParam(userID, c); strings.HasPrefix(value, "@") {
+		if user, err = nerdz.NewUserByUsername(value[1:]); err == nil {
+			setContentLocation(userID, user.ID(), c)
 		}
-		return nil, err
+	} else {
+		var id uint64
+		if id, err = strconv.ParseUint(value, 10, 64); err != nil {
+			if e := c.JSON(http.StatusBadRequest, &Response{
+				HumanMessage: "Invalid user identifier specified",
+				Message:      err.Error(),
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); e != nil {
+				log.Errorf("Error while writing response: %s", e.Error())
+			}
+			return nil, err
+		}
+		user, err = nerdz.NewUser(id)
 	}
 
-	var user *nerdz.User
-	if user, err = nerdz.NewUser(id); err != nil {
+	if err != nil {
 		if e := c.JSON(http.StatusBadRequest, &Response{
 			HumanMessage: "User does not exists",
 			Message:      err.Error(),
@@ -194,7 +205,7 @@ func User(userID string, c echo.Context) (*nerdz.User, error) {
 	}
 
 	me := c.Get("me").(*nerdz.User)
-	if !me.CanSee(user) {
+	if !me.CanSeeProfile(user) {
 		message := "You can't see the required profile"
 		if err = c.JSON(http.StatusUnauthorized, &Response{
 			HumanMessage: message,
@@ -211,24 +222,33 @@ func User(userID string, c echo.Context) (*nerdz.User, error) {
 
 // Project extract "id" from the url parameter, parse it and returns
 // the Project if the "me" (in the context) user is allowed to see it.
+// The parameter can be the numeric ID or ~name: in the latter case
+// the Content-Location header refers to the numeric ID.
 // Otherwise returns an error
 func Project(projectID string, c echo.Context) (*nerdz.Project, error) {
-	var id uint64
+	var project *nerdz.Project
 	var e error
-	if id, e = strconv.ParseUint(c.Param(projectID), 10, 64); e != nil {
-		if err := c.JSON(http.StatusBadRequest, &Response{
-			HumanMessage: "Invalid project identifier specified",
-			Message:      e.Error(),
-			Status:       http.StatusBadRequest,
-			Success:      false,
-		}); err != nil {
-			log.Errorf("Error while writing response: %s", err.Error())
+	if value := PathParam(projectID, c); strings.HasPrefix(value, "~") {
+		if project, e = nerdz.NewProjectByName(value[1:]); e == nil {
+			setContentLocation(projectID, project.ID(), c)
 		}
-		return nil, e
+	} else {
+		var id uint64
+		if id, e = strconv.ParseUint(value, 10, 64); e != nil {
+			if err := c.JSON(http.StatusBadRequest, &Response{
+				HumanMessage: "Invalid project identifier specified",
+				Message:      e.Error(),
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return nil, e
+		}
+		project, e = nerdz.NewProject(id)
 	}
 
-	var project *nerdz.Project
-	if project, e = nerdz.NewProject(id); e != nil {
+	if e != nil {
 		if err := c.JSON(http.StatusBadRequest, &Response{
 			HumanMessage: "Project does not exists",
 			Message:      e.Error(),
@@ -287,3 +307,33 @@ func GetProjectsInfo(projects []*nerdz.Project) (projectsInfo []*ProjectInfo) {
 	}
 	return
 }
+
+// setContentLocation sets the Content-Location header to the request path, with the path parameter
+// replaced by the numeric ID. Many path parameters can be replaced, calling it once per parameter
+func setContentLocation(param string, id uint64, c echo.Context) {
+	header := c.Response().Header()
+	location := header.Get("Content-Location")
+	if location == "" {
+		location = c.Request().URL.EscapedPath()
+	}
+
+	value := PathParam(param, c)
+	segments := strings.Split(location, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil && unescaped == value {
+			segments[i] = strconv.FormatUint(id, 10)
+			break
+		}
+	}
+	header.Set("Content-Location", strings.Join(segments, "/"))
+}
+
+// PathParam returns the unescaped value of the path parameter.
+// If the value is not a valid escaped string, it's returned as is
+func PathParam(name string, c echo.Context) string {
+	value, err := url.PathUnescape(c.Param(name))
+	if err != nil {
+		return c.Param(name)
+	}
+	return value
+}
@@ -0,0 +1,101 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package search
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// scopes are the scopes required to search the messages of every type
+var scopes = map[string]string{
+	nerdz.SearchUserPosts:       "profile_messages:read",
+	nerdz.SearchProjectPosts:    "project_messages:read",
+	nerdz.SearchUserComments:    "profile_comments:read",
+	nerdz.SearchProjectComments: "project_comments:read",
+	nerdz.SearchPms:             "pms:read",
+}
+
+// Search handles the request and returns the messages matching the search query
+func Search() echo.HandlerFunc {
+
+	// swagger:route GET /search search Search
+	//
+	// Searches the messages visible by the current user, the most recent first.
+	// The query (q) supports the web search syntax: "quoted phrases", OR and -excluded words.
+	// Every result contains a snippet of the message with the matching words within <mark></mark>.
+	//
+	// You can filter the results via the type, lang, since, until, author and board query string parameters.
+	// Without a type, the types of messages allowed by the scopes are searched.
+	// You can paginate the request setting until to the timestamp of the last result
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read project_messages:read profile_comments:read project_comments:read pms:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		options := c.Get("searchOptions").(*nerdz.SearchOptions)
+		if len(options.Types) == 1 {
+			if scope := scopes[options.Types[0]]; !rest.IsGranted(scope, c) {
+				return rest.InvalidScopeResponse(scope, c)
+			}
+		} else {
+			for _, searchType := range nerdz.SearchTypes {
+				if rest.IsGranted(scopes[searchType], c) {
+					options.Types = append(options.Types, searchType)
+				}
+			}
+			if len(options.Types) == 0 {
+				return rest.InvalidScopeResponse(scopes[nerdz.SearchUserPosts], c)
+			}
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		results, err := me.Search(*options)
+		if err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: "Unable to search: " + errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		var resultsTO []*nerdz.SearchResultTO
+		for _, r := range *results {
+			result := r
+			resultsTO = append(resultsTO, result.GetTO(me))
+		}
+		return rest.SelectFields(resultsTO, c)
+	}
+}
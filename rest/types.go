@@ -85,7 +85,7 @@ type ProjectInfo struct {
 
 // PostID is the post ID swagger parameter
 //
-// swagger:parameters GetUserPost DeleteUserPost EditUserPost GetUserPostComments GetUserPostComment NewUserPostComment EditUserPostComment DeleteUserPostComment GetUserPostVotes NewUserPostVote GetUserPostCommentsVotes NewUserPostCommentVote GetUserPostBookmarks NewUserPostBookmark DeleteUserPostBookmark GetUserPostLurks NewUserPostLurk DeleteUserPostLurk GetUserPostLock NewUserPostLock DeleteUserPostLock NewUserNewPostUserLock DeleteUserPostUserLock EditMePost DeleteMePostComment DeleteMePost EditMeComment NewMePostComment GetMePostVotes NewMePostVote GetMePostCommentsVotes NewMePostCommentVote GetMePostBookmarks NewMePostBookmark DeleteMePostBookmark GetMePostLurks NewMePostLurk DeleteMePostLurk GetMePostLock NewMePostLock DeleteMePostLock NewMeNewPostUserLock DeleteMePostUserLock getProjectPost DeleteProjectPost EditProjectPost getProjectPostComments GetProjectPostComment NewProjectPostComment EditProjectPostComment DeleteProjectPostComment GetProjectPostVotes NewProjectPostVote GetProjectPostCommentsVotes NewProjectPostCommentVote GetProjectPostBookmarks NewProjectPostBookmark DeleteProjectPostBookmark GetProjectPostLurks NewProjectPostLurk DeleteProjectPostLurk GetProjectPostLock NewProjectPostLock DeleteProjectPostLock NewUserNewPostProjectLock DeleteProjectPostUserLock GetMePostComment GetMePostComments GetMePost
+// swagger:parameters GetUserPost DeleteUserPost EditUserPost GetUserPostComments GetUserPostComment NewUserPostComment EditUserPostComment DeleteUserPostComment GetUserPostVotes NewUserPostVote GetUserPostCommentsVotes NewUserPostCommentVote GetUserPostBookmarks NewUserPostBookmark DeleteUserPostBookmark GetUserPostLurks NewUserPostLurk DeleteUserPostLurk GetUserPostLock NewUserPostLock DeleteUserPostLock NewUserNewPostUserLock DeleteUserPostUserLock EditMePost DeleteMePostComment DeleteMePost EditMeComment NewMePostComment GetMePostVotes NewMePostVote GetMePostCommentsVotes NewMePostCommentVote GetMePostBookmarks NewMePostBookmark DeleteMePostBookmark GetMePostLurks NewMePostLurk DeleteMePostLurk GetMePostLock NewMePostLock DeleteMePostLock NewMeNewPostUserLock DeleteMePostUserLock getProjectPost DeleteProjectPost EditProjectPost getProjectPostComments GetProjectPostComment NewProjectPostComment EditProjectPostComment DeleteProjectPostComment GetProjectPostVotes NewProjectPostVote GetProjectPostCommentsVotes NewProjectPostCommentVote GetProjectPostBookmarks NewProjectPostBookmark DeleteProjectPostBookmark GetProjectPostLurks NewProjectPostLurk DeleteProjectPostLurk GetProjectPostLock NewProjectPostLock DeleteProjectPostLock NewUserNewPostProjectLock DeleteProjectPostUserLock GetMePostComment GetMePostComments GetMePost NewUserPostPin DeleteUserPostPin NewMePostPin DeleteMePostPin NewProjectPostPin DeleteProjectPostPin GetUserPostSubscription NewUserPostSubscription DeleteUserPostSubscription GetMePostSubscription NewMePostSubscription DeleteMePostSubscription GetProjectPostSubscription NewProjectPostSubscription DeleteProjectPostSubscription NewUserPostClosure DeleteUserPostClosure NewMePostClosure DeleteMePostClosure NewProjectPostClosure DeleteProjectPostClosure
 type PostID struct {
 	// a Pid is the post id
 	//
@@ -96,24 +96,24 @@ type PostID struct {
 
 // ID is the ID of the referenced board, user or project
 //
-// swagger:parameters GetUserPosts GetUserPost NewUserPost DeleteUserPost EditUserPost GetUserPostComments GetUserPostComment NewUserPostComment EditUserPostComment DeleteUserPostComment GetUserInfo GetUserFriends GetUserFollowers GetUserFollowing GetProjectFollowing GetWhitelist GetWhitelisting GetBlacklist GetBlacklisting GetUserPostVotes NewUserPostVote GetUserPostCommentsVotes NewUserPostCommentVote GetUserPostBookmarks NewUserPostBookmark DeleteUserPostBookmark GetUserPostLurks NewUserPostLurk DeleteUserPostLurk GetUserPostLock NewUserPostLock DeleteUserPostLock NewUserNewPostUserLock DeleteUserPostUserLock getProjectPosts getProjectPost NewProjectPost DeleteProjectPost EditProjectPost getProjectPostComments GetProjectPostComment NewProjectPostComment EditProjectPostComment DeleteProjectPostComment getProjectInfo getProjectMembers getProjectFollowers GetProjectPostVotes NewProjectPostVote GetProjectPostCommentsVotes NewProjectPostCommentVote GetProjectPostBookmarks NewProjectPostBookmark DeleteProjectPostBookmark GetProjectPostLurks NewProjectPostLurk DeleteProjectPostLurk GetProjectPostLock NewProjectPostLock DeleteProjectPostLock NewUserNewPostProjectLock DeleteProjectPostUserLock
+// swagger:parameters GetUserPosts GetUserPost NewUserPost DeleteUserPost EditUserPost GetUserPostComments GetUserPostComment NewUserPostComment EditUserPostComment DeleteUserPostComment GetUserInfo GetUserFriends GetUserFollowers GetUserFollowing GetProjectFollowing GetWhitelist GetWhitelisting GetBlacklist GetBlacklisting GetUserPostVotes NewUserPostVote GetUserPostCommentsVotes NewUserPostCommentVote GetUserPostBookmarks NewUserPostBookmark DeleteUserPostBookmark GetUserPostLurks NewUserPostLurk DeleteUserPostLurk GetUserPostLock NewUserPostLock DeleteUserPostLock NewUserNewPostUserLock DeleteUserPostUserLock getProjectPosts getProjectPost NewProjectPost DeleteProjectPost EditProjectPost getProjectPostComments GetProjectPostComment NewProjectPostComment EditProjectPostComment DeleteProjectPostComment getProjectInfo UpdateProject DeleteProject TransferProject JoinProject LeaveProject KickProjectMember GetProjectInvites InviteToProject GetProjectJoinRequests ApproveProjectJoinRequest DenyProjectJoinRequest getProjectMembers GetProjectRoles SetProjectMemberRole getProjectFollowers GetProjectPostVotes NewProjectPostVote GetProjectPostCommentsVotes NewProjectPostCommentVote GetProjectPostBookmarks NewProjectPostBookmark DeleteProjectPostBookmark GetProjectPostLurks NewProjectPostLurk DeleteProjectPostLurk GetProjectPostLock NewProjectPostLock DeleteProjectPostLock NewUserNewPostProjectLock DeleteProjectPostUserLock GetUserPinnedPosts NewUserPostPin DeleteUserPostPin GetProjectPinnedPosts NewProjectPostPin DeleteProjectPostPin GetProjectStats GetUserPostSubscription NewUserPostSubscription DeleteUserPostSubscription GetProjectPostSubscription NewProjectPostSubscription DeleteProjectPostSubscription ExportProjectFollowers ExportProjectMembers NewUserPostClosure DeleteUserPostClosure NewProjectPostClosure DeleteProjectPostClosure NewUserDraft NewProjectDraft
 type ID struct {
-	// a ID is the User ID
+	// a ID is the User ID (or @username), or the Project ID (or ~name)
 	//
 	// in: path
 	// required: true
-	ID uint64 `json:"id"`
+	ID string `json:"id"`
 }
 
 // Target is the ID of the User referenced by the operation
 //
-// swagger:parameters NewUserNewPostUserLock DeleteUserPostUserLock NewMeNewPostUserLock DeleteMePostUserLock NewMeFollowing DeleteMeFollowing NewProjectFollowing DeleteProjectFollowing NewWhitelisted DeleteWhitelisted NewBlacklisted DeleteBlacklisted NewUserNewPostProjectLock DeleteProjectPostUserLock
+// swagger:parameters NewUserNewPostUserLock DeleteUserPostUserLock NewMeNewPostUserLock DeleteMePostUserLock NewMeFollowing DeleteMeFollowing NewProjectFollowing DeleteProjectFollowing NewWhitelisted DeleteWhitelisted NewBlacklisted DeleteBlacklisted NewUserNewPostProjectLock DeleteProjectPostUserLock TransferProject KickProjectMember InviteToProject ApproveProjectJoinRequest DenyProjectJoinRequest SetProjectMemberRole
 type Target struct {
-	// a Target is the ID of the User referenced by the operation
+	// a Target is the ID (or @username) of the User referenced by the operation
 	//
 	// in: path
 	// required: true
-	Target uint64 `json:"target"`
+	Target string `json:"target"`
 }
 
 // CommentID is the ID of the comment
@@ -129,7 +129,7 @@ type CommentID struct {
 
 // PmID is the ID of the PM
 //
-// swagger:parameters GetMePm EditMePm DeleteMePm
+// swagger:parameters GetMePm GetMePmRevisions EditMePm DeleteMePm ReadMePm
 type PmID struct {
 	// a PMID is the ID of the PM
 	//
@@ -140,11 +140,245 @@ type PmID struct {
 
 // OtherID is the ID of the other user
 //
-// swagger:parameters getMeConversation DeleteMePms GetMePm NewMePm EditMePm DeleteMePm
+// swagger:parameters getMeConversation DeleteMePms GetMePm GetMePmRevisions NewMePm EditMePm DeleteMePm ReadMePms ReadMePm
 type OtherID struct {
-	// Other is the ID of the other user
+	// Other is the ID (or @username) of the other user
 	//
 	// in:path
 	// required:true
-	Other uint64 `json:"other"`
+	Other string `json:"other"`
+}
+
+// NewPmGroup represents a new group conversation of the current user
+//
+// swagger:parameters NewMePmGroup
+type NewPmGroup struct {
+	// Name is the name of the group conversation
+	//
+	// in: body
+	Name string `json:"name"`
+	// Members are the IDs of the other participants
+	//
+	// in: body
+	Members []uint64 `json:"members"`
+}
+
+// PmGroupID is the ID of the group conversation
+//
+// swagger:parameters GetMePmGroup GetMePmGroupMessages NewMePmGroupMessage ReadMePmGroup AddMePmGroupMember RemoveMePmGroupMember GrantMePmGroupAdmin RevokeMePmGroupAdmin
+type PmGroupID struct {
+	// Group is the ID of the group conversation
+	//
+	// in:path
+	// required:true
+	Group uint64 `json:"group"`
+}
+
+// TargetID is the ID of the participant of the group conversation
+//
+// swagger:parameters AddMePmGroupMember RemoveMePmGroupMember GrantMePmGroupAdmin RevokeMePmGroupAdmin
+type TargetID struct {
+	// Target is the ID (or @username) of the participant
+	//
+	// in:path
+	// required:true
+	Target string `json:"target"`
+}
+
+// NewWebhook represents a new webhook of an application of the current user
+//
+// swagger:parameters NewMeAppWebhook
+type NewWebhook struct {
+	// URL is the absolute http or https URL that receives the events
+	//
+	// in: body
+	URL string `json:"url"`
+	// Event is the type of the events delivered to the URL: post, comment, mention, follower or pm.
+	// The post events are delivered also for the posts on the projects owned by the user or of which the user is a member
+	Event string `json:"event"`
+}
+
+// AppID is the ID of the OAuth2 application
+//
+// swagger:parameters GetMeAppWebhooks NewMeAppWebhook DeleteMeAppWebhook GetMeAppWebhookDeliveries
+type AppID struct {
+	// App is the ID of the OAuth2 application
+	//
+	// in:path
+	// required:true
+	App uint64 `json:"app"`
+}
+
+// WebhookID is the ID of the webhook
+//
+// swagger:parameters DeleteMeAppWebhook GetMeAppWebhookDeliveries
+type WebhookID struct {
+	// Wid is the ID of the webhook
+	//
+	// in:path
+	// required:true
+	Wid uint64 `json:"wid"`
+}
+
+// InfoUpdate represents the changes to the information of the current user.
+// The missing fields are left unchanged
+//
+// swagger:parameters UpdateMeInfo
+type InfoUpdate struct {
+	// Name is the name of the user
+	//
+	// in: body
+	Name *string `json:"name,omitempty"`
+	// Surname is the surname of the user
+	Surname *string `json:"surname,omitempty"`
+	// Timezone is a timezone of the tz database, like Europe/Rome
+	Timezone *string `json:"timezone,omitempty"`
+	// Lang is the language of the user
+	Lang *string `json:"lang,omitempty"`
+	// BoardLang is the language of the posts on the user board
+	BoardLang *string `json:"boardLang,omitempty"`
+	// Viewonline is false when the user hides the online status
+	Viewonline *bool `json:"viewonline,omitempty"`
+}
+
+// ProfileUpdate represents the changes to the profile of the current user.
+// The missing fields are left unchanged
+//
+// swagger:parameters UpdateMeProfile
+type ProfileUpdate struct {
+	// Biography is the biography of the user
+	//
+	// in: body
+	Biography *string `json:"biography,omitempty"`
+	// Quotes are the quotes of the user, one per line
+	Quotes *string `json:"quotes,omitempty"`
+	// Website is the absolute http or https URL of the website of the user
+	Website *string `json:"website,omitempty"`
+	// Github is the URL of the GitHub profile of the user
+	Github *string `json:"github,omitempty"`
+	// Telegram is the URL of the Telegram profile of the user
+	Telegram *string `json:"telegram,omitempty"`
+	// Template is the number of the template of the user
+	Template *uint8 `json:"template,omitempty"`
+	// Dateformat is the date format of the user
+	Dateformat *string `json:"dateformat,omitempty"`
+}
+
+// BoardUpdate represents the changes to the board settings of the current user.
+// The missing fields are left unchanged
+//
+// swagger:parameters UpdateMeBoard
+type BoardUpdate struct {
+	// Closed is true when only the whitelisted users can write posts on the board
+	//
+	// in: body
+	Closed *bool `json:"closed,omitempty"`
+	// Private is true when only the whitelisted users can see the board
+	Private *bool `json:"private,omitempty"`
+	// WhitelistOnly is true when only the whitelisted users can write posts and comments on the board
+	WhitelistOnly *bool `json:"whitelistOnly,omitempty"`
+}
+
+// NewDraft represents a new draft, or a new scheduled post, of the current user
+//
+// swagger:parameters NewUserDraft NewMeDraft NewProjectDraft EditMeDraft
+type NewDraft struct {
+	// Message is the message of the post
+	//
+	// in: body
+	Message string `json:"message"`
+	// Lang is the language of the post, the language of the current user at the publication if empty
+	Lang string `json:"lang,omitempty"`
+	// PublishAt is the unix timestamp of the publication of a scheduled post, 0 for a draft
+	PublishAt int64 `json:"publishAt,omitempty"`
+}
+
+// DraftID is the ID of the draft
+//
+// swagger:parameters GetMeDraft EditMeDraft DeleteMeDraft PublishMeDraft
+type DraftID struct {
+	// Draft is the ID of the draft
+	//
+	// in:path
+	// required:true
+	Draft uint64 `json:"draft"`
+}
+
+// NewProject represents a new project of the current user
+//
+// swagger:parameters NewProject
+type NewProject struct {
+	// Name is the unique name of the project: letters, numbers, '_', '-' and '.'
+	//
+	// in: body
+	Name string `json:"name"`
+	// Description is the description of the project
+	Description string `json:"description"`
+	// Goal is the goal of the project
+	Goal string `json:"goal,omitempty"`
+	// Website is the absolute http or https URL of the website of the project
+	Website string `json:"website,omitempty"`
+	// Photo is the absolute http or https URL of the photo of the project
+	Photo string `json:"photo,omitempty"`
+	// Visible is false when the project is visible only by its members
+	Visible bool `json:"visible"`
+	// Private is true when the project is private
+	Private bool `json:"private"`
+	// Open is true when every user can write on the project board, false when only the members can
+	Open bool `json:"open"`
+}
+
+// ProjectUpdate represents the changes to the project.
+// The missing fields are left unchanged
+//
+// swagger:parameters UpdateProject
+type ProjectUpdate struct {
+	// Description is the description of the project
+	//
+	// in: body
+	Description *string `json:"description,omitempty"`
+	// Goal is the goal of the project
+	Goal *string `json:"goal,omitempty"`
+	// Website is the absolute http or https URL of the website of the project, empty to remove it
+	Website *string `json:"website,omitempty"`
+	// Photo is the absolute http or https URL of the photo of the project, empty to remove it
+	Photo *string `json:"photo,omitempty"`
+	// Visible is false when the project is visible only by its members
+	Visible *bool `json:"visible,omitempty"`
+	// Private is true when the project is private
+	Private *bool `json:"private,omitempty"`
+	// Open is true when every user can write on the project board, false when only the members can
+	Open *bool `json:"open,omitempty"`
+}
+
+// ProjectRole represents the new role of a member of the project
+//
+// swagger:parameters SetProjectMemberRole
+type ProjectRole struct {
+	// Role is the role: admin, moderator or member
+	//
+	// in: body
+	// required: true
+	Role string `json:"role"`
+}
+
+// NewInterest represents a new interest of the current user
+//
+// swagger:parameters NewMeInterest
+type NewInterest struct {
+	// Value is the interest
+	//
+	// in: body
+	Value string `json:"value"`
+}
+
+// InterestValue is the interest in the path
+//
+// swagger:parameters DeleteMeInterest GetInterestUsers
+type InterestValue struct {
+	// Value is the interest
+	//
+	// in:path
+	// required:true
+	Value string `json:"value"`
 }
@@ -28,7 +28,7 @@ import (
 	"github.com/nerdzeu/nerdz-api/rest"
 )
 
-// SetOther is the middleware that checks if the current logged user can see the required profile
+// SetOther is the middleware that checks if the current logged user can see the required board
 // and if the required profile exists. On success sets the "other" = *User variable in the context
 func SetOther() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -38,6 +38,19 @@ func SetOther() echo.MiddlewareFunc {
 			if other, err = rest.User("id", c); err != nil {
 				return err
 			}
+			// the private boards are visible only by their owners and the whitelisted users
+			if me := c.Get("me").(*nerdz.User); !me.CanSee(other) {
+				message := "You can't see the required board"
+				if err = c.JSON(http.StatusUnauthorized, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusUnauthorized,
+					Success:      false,
+				}); err != nil {
+					log.Errorf("Error while writing response: %s", err.Error())
+				}
+				return errors.New("you can't see the required board")
+			}
 			// store the other User into the context
 			c.Set("other", other)
 			// pass context to the next handler
@@ -147,21 +160,15 @@ func SetPm() echo.MiddlewareFunc {
 
 			// other is the owner of the pm list
 			other := c.Get("other").(*nerdz.User)
-			// otherID is the ID of the second actor in the conversation
-			var otherID, pmID uint64
+			// second is the second actor in the conversation
+			var second *nerdz.User
 			var e error
-			if otherID, e = strconv.ParseUint(c.Param("other"), 10, 64); e != nil {
-				errstr := "invalid user identifier specified"
-				if err := c.JSON(http.StatusBadRequest, &rest.Response{
-					HumanMessage: errstr,
-					Message:      e.Error(),
-					Status:       http.StatusBadRequest,
-					Success:      false,
-				}); err != nil {
-					log.Errorf("Error while writing response: %s", err.Error())
-				}
+			if second, e = rest.User("other", c); e != nil {
 				return e
 			}
+			otherID := second.ID()
+
+			var pmID uint64
 
 			if pmID, e = strconv.ParseUint(c.Param("pmid"), 10, 64); e != nil {
 				errstr := "invalid PM identifier specified"
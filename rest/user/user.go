@@ -18,8 +18,11 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package user
 
 import (
+	"database/sql"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
@@ -179,6 +182,75 @@ func NewPost() echo.HandlerFunc {
 	}
 }
 
+// NewDraft handles the request and saves a new draft, or a new scheduled post, on the board
+func NewDraft() echo.HandlerFunc {
+
+	// swagger:route POST /users/{id}/drafts users post drafts NewUserDraft
+	//
+	// Saves a new draft of a post on the board. When publishAt is set, the post is scheduled
+	// and published at that time with the same validation and notifications of a new post
+	//
+	// Consumes:
+	// - application/json
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+
+		// Read a rest.NewDraft from the body request.
+		message := rest.NewDraft{}
+		if err := c.Bind(&message); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+
+		draft := nerdz.Draft{
+			To:      c.Get("other").(*nerdz.User).ID(),
+			Type:    nerdz.UserBoardID,
+			Message: message.Message,
+			Lang:    message.Lang,
+		}
+		if message.PublishAt != 0 {
+			draft.PublishAt = sql.NullTime{Time: time.Unix(message.PublishAt, 0), Valid: true}
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		if err := me.SaveDraft(&draft); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(draft.GetTO(me), c)
+	}
+}
+
 // DeletePost handles the request and deletes the specified post
 func DeletePost() echo.HandlerFunc {
 
@@ -373,6 +445,80 @@ func PostComment() echo.HandlerFunc {
 	}
 }
 
+// PostRevisions handles the request and returns the revisions of the post
+func PostRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /users/{id}/posts/{pid}/revisions users post revisions GetUserPostRevisions
+	//
+	// Shows the revisions of the post, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:read", c) {
+			return rest.InvalidScopeResponse("profile_messages:read", c)
+		}
+		diff, _ := strconv.ParseBool(c.QueryParam("diff"))
+		revisions := c.Get("post").(*nerdz.UserPost).History()
+		me := c.Get("me").(*nerdz.User)
+
+		var revisionsTO []*nerdz.UserPostRevisionTO
+		for i, revision := range revisions {
+			revisionTO := revision.GetTO(me)
+			if diff && i > 0 {
+				revisionTO.Diff = nerdz.RevisionDiff(revisions[i-1].Message, revision.Message, int(revision.RevNo))
+			}
+			revisionsTO = append(revisionsTO, revisionTO)
+		}
+		return rest.SelectFields(revisionsTO, c)
+	}
+}
+
+// PostCommentRevisions handles the request and returns the revisions of the comment
+func PostCommentRevisions() echo.HandlerFunc {
+
+	// swagger:route GET /users/{id}/posts/{pid}/comments/{cid}/revisions users post comment revisions GetUserPostCommentRevisions
+	//
+	// Shows the revisions of the comment, from the first one.
+	// If the diff parameter is true, every revision contains the unified diff against the previous revision
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_comments:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_comments:read", c) {
+			return rest.InvalidScopeResponse("profile_comments:read", c)
+		}
+		diff, _ := strconv.ParseBool(c.QueryParam("diff"))
+		revisions := c.Get("comment").(*nerdz.UserPostComment).History()
+		me := c.Get("me").(*nerdz.User)
+
+		var revisionsTO []*nerdz.UserPostCommentRevisionTO
+		for i, revision := range revisions {
+			revisionTO := revision.GetTO(me)
+			if diff && i > 0 {
+				revisionTO.Diff = nerdz.RevisionDiff(revisions[i-1].Message, revision.Message, int(revision.RevNo))
+			}
+			revisionsTO = append(revisionsTO, revisionTO)
+		}
+		return rest.SelectFields(revisionsTO, c)
+	}
+}
+
 // NewPostComment handles the request and creates a new post
 func NewPostComment() echo.HandlerFunc {
 
@@ -586,6 +732,32 @@ func Info() echo.HandlerFunc {
 	}
 }
 
+// Presence handles the request and returns the online status of the user
+func Presence() echo.HandlerFunc {
+
+	// swagger:route GET /users/{id}/presence users info presence GetUserPresence
+	//
+	// Shows the online status and the last activity of the specified user.
+	// The users that hide their online status are always offline, with no last activity
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:read", c) {
+			return rest.InvalidScopeResponse("profile:read", c)
+		}
+		other := c.Get("other").(*nerdz.User)
+		return rest.SelectFields(other.Presence().GetTO(), c)
+	}
+}
+
 // Friends handles the request and returns the user friends
 func Friends() echo.HandlerFunc {
 
@@ -1548,3 +1720,325 @@ func DeletePostUserLock() echo.HandlerFunc {
 		})
 	}
 }
+
+// Pinned handles the request and returns the posts pinned to the top of the user board
+func Pinned() echo.HandlerFunc {
+
+	// swagger:route GET /users/{id}/pinned users posts GetUserPinnedPosts
+	//
+	// List the posts pinned to the top of the user board, from the newest
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:read", c) {
+			return rest.InvalidScopeResponse("profile_messages:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		var postsAPI []*nerdz.PostTO
+		for _, p := range *c.Get("other").(*nerdz.User).PinnedPosts() {
+			postsAPI = append(postsAPI, p.(*nerdz.UserPost).GetTO(me))
+		}
+		return rest.SelectFields(postsAPI, c)
+	}
+}
+
+// NewPostPin handles the request and pins the post to the top of the board
+func NewPostPin() echo.HandlerFunc {
+
+	// swagger:route POST /users/{id}/posts/{pid}/pin users post pin NewUserPostPin
+	//
+	// Pins the current post to the top of the board. Only the owner of the board can pin.
+	// A board has at most 3 pinned posts
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.UserPost)
+		if err := me.Pin(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// DeletePostPin handles the request and removes the post from the top of the board
+func DeletePostPin() echo.HandlerFunc {
+
+	// swagger:route DELETE /users/{id}/posts/{pid}/pin users post pin DeleteUserPostPin
+	//
+	// Removes the current post from the top of the board
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.UserPost)
+		if err := me.Unpin(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// NewPostClosure handles the request and closes the post
+func NewPostClosure() echo.HandlerFunc {
+
+	// swagger:route POST /users/{id}/posts/{pid}/close users post close NewUserPostClosure
+	//
+	// Closes the current post: only the owners of the post can close it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.UserPost)
+		if err := me.Close(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// DeletePostClosure handles the request and reopens the closed post
+func DeletePostClosure() echo.HandlerFunc {
+
+	// swagger:route DELETE /users/{id}/posts/{pid}/close users post close DeleteUserPostClosure
+	//
+	// Reopens the current post: only the owners of the post can reopen it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		post := c.Get("post").(*nerdz.UserPost)
+		if err := me.Reopen(post); err != nil {
+			errstr := err.Error()
+			if err := c.JSON(http.StatusBadRequest, &rest.Response{
+				Data:         nil,
+				HumanMessage: errstr,
+				Message:      errstr,
+				Status:       http.StatusBadRequest,
+				Success:      false,
+			}); err != nil {
+				log.Errorf("Error while writing response: %s", err.Error())
+			}
+			return errors.New(errstr)
+		}
+		return rest.SelectFields(post.GetTO(me), c)
+	}
+}
+
+// PostSubscription handles the request and returns the subscription of the current user to the comments of the post
+func PostSubscription() echo.HandlerFunc {
+
+	// swagger:route GET /users/{id}/posts/{pid}/subscription users post subscription GetUserPostSubscription
+	//
+	// Shows if the current user receives the comment notifications of the post, and why
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:read", c) {
+			return rest.InvalidScopeResponse("profile_messages:read", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Subscription)
+	}
+}
+
+// NewPostSubscription handles the request and subscribes the current user to the comments of the post
+func NewPostSubscription() echo.HandlerFunc {
+
+	// swagger:route PUT /users/{id}/posts/{pid}/subscription users post subscription NewUserPostSubscription
+	//
+	// Subscribes the current user to the comments of the post: the post is unmuted and,
+	// if the current user is neither the author nor a commenter, the post is lurked
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Subscribe)
+	}
+}
+
+// DeletePostSubscription handles the request and mutes the comment notifications of the post
+func DeletePostSubscription() echo.HandlerFunc {
+
+	// swagger:route DELETE /users/{id}/posts/{pid}/subscription users post subscription DeleteUserPostSubscription
+	//
+	// Mutes the comment notifications of the post, without unlurking it
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile_messages:write
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile_messages:write", c) {
+			return rest.InvalidScopeResponse("profile_messages:write", c)
+		}
+		return postSubscription(c, c.Get("me").(*nerdz.User).Unsubscribe)
+	}
+}
+
+// postSubscription applies the action to the post and writes the resulting subscription
+func postSubscription(c echo.Context, action func(nerdz.ExistingPost) (*nerdz.PostSubscription, error)) error {
+	subscription, err := action(c.Get("post").(*nerdz.UserPost))
+	if err != nil {
+		errstr := err.Error()
+		if err := c.JSON(http.StatusBadRequest, &rest.Response{
+			Data:         nil,
+			HumanMessage: errstr,
+			Message:      errstr,
+			Status:       http.StatusBadRequest,
+			Success:      false,
+		}); err != nil {
+			log.Errorf("Error while writing response: %s", err.Error())
+		}
+		return errors.New(errstr)
+	}
+	return rest.SelectFields(subscription.GetTO(c.Get("me").(*nerdz.User)), c)
+}
+
+// Search handles the request and returns the users whose username matches the query
+func Search() echo.HandlerFunc {
+
+	// swagger:route GET /users users info SearchUsers
+	//
+	// Searches the users by username: the usernames starting with the query (q) come first, then the most similar usernames.
+	// With autocomplete=true only the usernames starting with the query match, and only the basic information is returned.
+	// With username=<username> only the user with that (case insensitive) username is returned.
+	// The users that put the current user in their blacklist are not returned, nor are the users with a private board
+	// that did not put the current user in their whitelist
+	//
+	//	Produces:
+	//	- application/json
+	//
+	//	Security:
+	//		oauth: profile:read
+	//
+	//	Responses:
+	//		default: apiResponse
+
+	return func(c echo.Context) error {
+		if !rest.IsGranted("profile:read", c) {
+			return rest.InvalidScopeResponse("profile:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		options := c.Get("directoryOptions").(*nerdz.DirectoryOptions)
+		users := me.SearchUsers(*options)
+		if options.Autocomplete {
+			var infos []*nerdz.InfoTO
+			for _, user := range users {
+				infos = append(infos, user.Info().GetTO())
+			}
+			return rest.SelectFields(infos, c)
+		}
+		return rest.SelectFields(rest.GetUsersInfo(users), c)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -428,6 +429,33 @@ func TestGETAndOnGroupUsers(t *testing.T) {
 	}
 }
 
+func TestUsernameAndProjectNamePathParams(t *testing.T) {
+	user, _ := nerdz.NewUser(1)
+	project, _ := nerdz.NewProject(1)
+
+	at := setUP()
+	defer cleanUP()
+
+	endpoints := map[string]string{
+		"/v1/users/@" + strings.ToUpper(user.Username) + "/friends": "/v1/users/1/friends",
+		"/v1/projects/~" + url.PathEscape(project.Name):             "/v1/projects/1",
+		"/v1/users/1/friends": "",
+	}
+	for endpoint, location := range endpoints {
+		res := GETRequest(endpoint, at.AccessToken)
+		if res.Code != http.StatusOK {
+			t.Fatalf("Error in GET request %s: status code=%d", endpoint, res.Code)
+		}
+		if got := res.Header().Get("Content-Location"); got != location {
+			t.Errorf("Expected Content-Location %q for %s, got %q", location, endpoint, got)
+		}
+	}
+
+	if res := GETRequest("/v1/users/@this-user-does-not-exist", at.AccessToken); res.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown username, got %d", http.StatusBadRequest, res.Code)
+	}
+}
+
 func TestMeOnlyRoute(t *testing.T) {
 	var mapData igor.JSON
 	at := setUP()
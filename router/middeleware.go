@@ -23,9 +23,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/galeone/igor"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
 	"github.com/nerdzeu/nerdz-api/nerdz"
 	"github.com/nerdzeu/nerdz-api/rest"
 	"github.com/nerdzeu/nerdz-api/utils"
@@ -70,6 +72,11 @@ func authorization() echo.MiddlewareFunc {
 			}
 			c.Set("me", me)
 
+			// every authenticated request is an activity of the user
+			if err = me.Touch(); err != nil {
+				log.Errorf("Unable to record the activity of user %d: %s", me.ID(), err.Error())
+			}
+
 			// store the Access Data into the context
 			c.Set("accessData", accessData)
 			scopes := strings.Split(accessData.Scope, " ")
@@ -173,6 +180,7 @@ func setPostlist() echo.MiddlewareFunc {
 			newer, _ := strconv.ParseUint(new, 10, 64)
 
 			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+			viewer, _ := c.Get("me").(*nerdz.User)
 
 			c.Set("postlistOptions", &nerdz.PostlistOptions{
 				Following:  following,
@@ -183,6 +191,7 @@ func setPostlist() echo.MiddlewareFunc {
 				OlderModel: olderModel,
 				Newer:      newer,
 				NewerModel: newerModel,
+				Viewer:     viewer,
 			})
 
 			return next(c)
@@ -239,3 +248,173 @@ func setPmsOptions() echo.MiddlewareFunc {
 		})
 	}
 }
+
+// setMentionsOptions is the middleware that sets "mentionsOptions" = *nerdz.MentionsOptions into the current Context
+// handle GET parameters:
+// unread: if setted to true, requires only the mentions not yet notified
+// older: if setted to an existing mention ID, requires mentions older than the "older" value
+// newer: if setted to an existing mention ID, requires mentions newer than the "newer" value
+// n: if setted, define the number of mentions to retrieve. Follows the nerdz.AtMostMentions rules
+func setMentionsOptions() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			unread, _ := strconv.ParseBool(c.QueryParam("unread"))
+			older, _ := strconv.ParseUint(c.QueryParam("older"), 10, 64)
+			newer, _ := strconv.ParseUint(c.QueryParam("newer"), 10, 64)
+			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+
+			c.Set("mentionsOptions", &nerdz.MentionsOptions{
+				Unread: unread,
+				N:      nerdz.AtMostMentions(n),
+				Older:  older,
+				Newer:  newer,
+			})
+			return next(c)
+		})
+	}
+}
+
+// setUsersOptions is the middleware that sets "usersOptions" = *nerdz.UsersOptions into the current Context
+// handle GET parameters:
+// n: the number of users to return
+// older: if setted to an existing user ID, requires users registered before that user
+func setUsersOptions() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			older, _ := strconv.ParseUint(c.QueryParam("older"), 10, 64)
+			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+
+			c.Set("usersOptions", &nerdz.UsersOptions{
+				N:     nerdz.AtMostUsers(n),
+				Older: older,
+			})
+			return next(c)
+		})
+	}
+}
+
+// setSearchOptions is the middleware that sets "searchOptions" = *nerdz.SearchOptions into the current Context
+// handle GET parameters:
+// q: the search query
+// type: if setted, the type of the messages to search. One of nerdz.SearchTypes
+// lang: if setted, search only the messages in this language
+// since, until: if setted, UNIX timestamps that limit the creation time of the messages
+// author: if setted, search only the messages sent by this user
+// board: if setted, search only the messages on this board (the other user of the conversation, for the pms)
+// n: if setted, define the number of results to retrieve. Follows the nerdz.AtMostSearchResults rules
+func setSearchOptions() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			var types []string
+			if searchType := c.QueryParam("type"); searchType != "" {
+				if !utils.InSlice(searchType, nerdz.SearchTypes) {
+					message := "Unsupported type " + searchType + ". Allowed types: " + strings.Join(nerdz.SearchTypes, ", ")
+					return c.JSON(http.StatusBadRequest, &rest.Response{
+						HumanMessage: message,
+						Message:      message,
+						Status:       http.StatusBadRequest,
+						Success:      false,
+					})
+				}
+				types = []string{searchType}
+			}
+
+			lang := c.QueryParam("lang")
+			if lang != "" && !utils.InSlice(lang, nerdz.Configuration.Languages) {
+				message := "Not supported language: " + lang
+				return c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				})
+			}
+
+			var since, until time.Time
+			if timestamp, err := strconv.ParseInt(c.QueryParam("since"), 10, 64); err == nil {
+				since = time.Unix(timestamp, 0).UTC()
+			}
+			if timestamp, err := strconv.ParseInt(c.QueryParam("until"), 10, 64); err == nil {
+				until = time.Unix(timestamp, 0).UTC()
+			}
+
+			author, _ := strconv.ParseUint(c.QueryParam("author"), 10, 64)
+			board, _ := strconv.ParseUint(c.QueryParam("board"), 10, 64)
+			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+
+			c.Set("searchOptions", &nerdz.SearchOptions{
+				Query:    c.QueryParam("q"),
+				Types:    types,
+				Language: lang,
+				Since:    since,
+				Until:    until,
+				Author:   author,
+				Board:    board,
+				N:        nerdz.AtMostSearchResults(n),
+			})
+			return next(c)
+		})
+	}
+}
+
+// setDirectoryOptions is the middleware that sets "directoryOptions" = *nerdz.DirectoryOptions into the current Context
+// handle GET parameters:
+// q: the prefix of, or a name similar to, the name to search
+// autocomplete: if setted to true, only the names starting with q match
+// exactParam: if setted, only the name equal to its value matches. It has the precedence over q
+// n: if setted, define the number of results to retrieve. Follows the nerdz.AtMostUsers rules
+func setDirectoryOptions(exactParam string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			query, exact := c.QueryParam("q"), false
+			if name := c.QueryParam(exactParam); name != "" {
+				query, exact = name, true
+			}
+			autocomplete, _ := strconv.ParseBool(c.QueryParam("autocomplete"))
+			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+
+			c.Set("directoryOptions", &nerdz.DirectoryOptions{
+				Query:        query,
+				Autocomplete: autocomplete,
+				Exact:        exact,
+				N:            nerdz.AtMostUsers(n),
+			})
+			return next(c)
+		})
+	}
+}
+
+// setProjectStatsOptions is the middleware that sets "projectStatsOptions" = *nerdz.ProjectStatsOptions into the current Context
+// handle GET parameters:
+// interval: the interval of the buckets, between nerdz.StatsIntervals. Default: day
+// n: if setted, define the number of buckets to compute. Follows the nerdz.AtMostStatsBuckets rules
+// until: if setted to a unix timestamp, the last bucket is the one containing that time, instead of now
+func setProjectStatsOptions() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			interval := c.QueryParam("interval")
+			if interval != "" && !utils.InSlice(interval, nerdz.StatsIntervals) {
+				message := "Unsupported interval " + interval + ". Allowed intervals: " + strings.Join(nerdz.StatsIntervals, ", ")
+				return c.JSON(http.StatusBadRequest, &rest.Response{
+					HumanMessage: message,
+					Message:      message,
+					Status:       http.StatusBadRequest,
+					Success:      false,
+				})
+			}
+
+			var until time.Time
+			if timestamp, err := strconv.ParseInt(c.QueryParam("until"), 10, 64); err == nil {
+				until = time.Unix(timestamp, 0).UTC()
+			}
+			n, _ := strconv.ParseUint(c.QueryParam("n"), 10, 8)
+
+			c.Set("projectStatsOptions", &nerdz.ProjectStatsOptions{
+				Interval: interval,
+				N:        nerdz.AtMostStatsBuckets(n),
+				Until:    until,
+			})
+			return next(c)
+		})
+	}
+}
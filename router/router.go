@@ -24,8 +24,10 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/nerdzeu/nerdz-api/nerdz"
 	"github.com/nerdzeu/nerdz-api/oauth2"
+	"github.com/nerdzeu/nerdz-api/rest/interest"
 	"github.com/nerdzeu/nerdz-api/rest/me"
 	"github.com/nerdzeu/nerdz-api/rest/project"
+	"github.com/nerdzeu/nerdz-api/rest/search"
 	"github.com/nerdzeu/nerdz-api/rest/user"
 	"github.com/nerdzeu/nerdz-api/stream"
 	"github.com/openshift/osin"
@@ -76,6 +78,16 @@ func Init(enableLog bool) *echo.Echo {
 	o.POST("/token", oauth2.Token())
 	o.GET("/info", oauth2.Info())
 
+	/**************************************************************************
+	* ROUTE /users and /projects directories, and the creation of the projects
+	* Authorization required
+	* Registered outside the groups, that set the user (project) :id in the context
+	***************************************************************************/
+	// uses setDirectoryOptions middleware
+	basePath.GET("/users", user.Search(), authorization(), setDirectoryOptions("username"))
+	basePath.GET("/projects", project.Search(), authorization(), setDirectoryOptions("name"))
+	basePath.POST("/projects", project.New(), authorization())
+
 	/**************************************************************************
 	* ROUTE /users/:id
 	* Authorization required
@@ -84,6 +96,7 @@ func Init(enableLog bool) *echo.Echo {
 	usersG.Use(authorization())
 	usersG.Use(user.SetOther())
 	usersG.GET("/:id", user.Info())
+	usersG.GET("/:id/presence", user.Presence())
 	usersG.GET("/:id/friends", user.Friends())
 	usersG.GET("/:id/followers", user.Followers())
 	usersG.GET("/:id/whitelist", user.Whitelist())
@@ -94,11 +107,14 @@ func Init(enableLog bool) *echo.Echo {
 	usersG.GET("/:id/following/projects", user.ProjectFollowing())
 	// uses setPostlist middleware
 	usersG.GET("/:id/posts", user.Posts(), setPostlist())
+	usersG.GET("/:id/pinned", user.Pinned())
 	usersG.POST("/:id/posts", user.NewPost())
+	usersG.POST("/:id/drafts", user.NewDraft())
 	// requests below uses the user.SetPost() middleware to refer to the requested post
 	usersG.GET("/:id/posts/:pid", user.Post(), user.SetPost())
 	usersG.PUT("/:id/posts/:pid", user.EditPost(), user.SetPost())
 	usersG.DELETE("/:id/posts/:pid", user.DeletePost(), user.SetPost())
+	usersG.GET("/:id/posts/:pid/revisions", user.PostRevisions(), user.SetPost())
 	// Votes
 	usersG.GET("/:id/posts/:pid/votes", user.PostVotes(), user.SetPost())
 	// Vote can be used to add/edit/delete the vote, just changing the vote value
@@ -117,6 +133,14 @@ func Init(enableLog bool) *echo.Echo {
 	usersG.DELETE("/:id/posts/:pid/locks", user.DeletePostLock(), user.SetPost())
 	usersG.POST("/:id/posts/:pid/locks/:target", user.NewPostUserLock(), user.SetPost())
 	usersG.DELETE("/:id/posts/:pid/locks/:target", user.DeletePostUserLock(), user.SetPost())
+
+	usersG.POST("/:id/posts/:pid/pin", user.NewPostPin(), user.SetPost())
+	usersG.DELETE("/:id/posts/:pid/pin", user.DeletePostPin(), user.SetPost())
+	usersG.POST("/:id/posts/:pid/close", user.NewPostClosure(), user.SetPost())
+	usersG.DELETE("/:id/posts/:pid/close", user.DeletePostClosure(), user.SetPost())
+	usersG.GET("/:id/posts/:pid/subscription", user.PostSubscription(), user.SetPost())
+	usersG.PUT("/:id/posts/:pid/subscription", user.NewPostSubscription(), user.SetPost())
+	usersG.DELETE("/:id/posts/:pid/subscription", user.DeletePostSubscription(), user.SetPost())
 	// uses setCommentList middleware
 	usersG.GET("/:id/posts/:pid/comments", user.PostComments(), user.SetPost(), setCommentList())
 	usersG.POST("/:id/posts/:pid/comments", user.NewPostComment(), user.SetPost())
@@ -124,6 +148,7 @@ func Init(enableLog bool) *echo.Echo {
 	usersG.GET("/:id/posts/:pid/comments/:cid", user.PostComment(), user.SetPost(), user.SetComment())
 	usersG.PUT("/:id/posts/:pid/comments/:cid", user.EditPostComment(), user.SetPost(), user.SetComment())
 	usersG.DELETE("/:id/posts/:pid/comments/:cid", user.DeletePostComment(), user.SetPost(), user.SetComment())
+	usersG.GET("/:id/posts/:pid/comments/:cid/revisions", user.PostCommentRevisions(), user.SetPost(), user.SetComment())
 	// Votes
 	usersG.GET("/:id/posts/:pid/comments/:cid/votes", user.PostCommentVotes(), user.SetPost(), user.SetComment())
 	usersG.POST("/:id/posts/:pid/comments/:cid/votes", user.NewPostCommentVote(), user.SetPost(), user.SetComment())
@@ -137,8 +162,15 @@ func Init(enableLog bool) *echo.Echo {
 	meG.Use(me.SetOther())
 	// Read only
 	meG.GET("", me.Info())
+	meG.PATCH("", me.UpdateInfo())
+	meG.PATCH("/profile", me.UpdateProfile())
+	meG.GET("/board", me.Board())
+	meG.PATCH("/board", me.UpdateBoard())
 	meG.GET("/friends", me.Friends())
 	meG.GET("/followers", me.Followers())
+	meG.GET("/interests", me.Interests())
+	meG.POST("/interests", me.NewInterest())
+	meG.DELETE("/interests/:value", me.DeleteInterest())
 	// Read & write
 	meG.GET("/following/users", me.UserFollowing())
 	meG.POST("/following/users/:target", me.NewUserFollowing())
@@ -158,24 +190,52 @@ func Init(enableLog bool) *echo.Echo {
 	// Read only
 	meG.GET("/blacklisting", me.Blacklisting())
 	meG.GET("/home", me.Home(), setPostlist())
+	meG.GET("/bookmarks", me.Bookmarks(), setPostlist())
+	meG.GET("/subscriptions", me.Subscriptions(), setPostlist())
+	meG.GET("/lurks", me.Lurks(), setPostlist())
+	meG.GET("/mentions", me.Mentions(), setMentionsOptions())
+	meG.POST("/mentions/read", me.ReadMentions())
 	meG.GET("/pms", me.Conversations())
+	meG.GET("/pms/unread", me.UnreadConversations())
+	// group conversations: requests with the group parameter use the me.SetPmGroup() middleware
+	meG.GET("/pms/groups", me.PmGroups())
+	meG.POST("/pms/groups", me.NewPmGroup())
+	meG.GET("/pms/groups/:group", me.PmGroup(), me.SetPmGroup())
+	meG.GET("/pms/groups/:group/messages", me.PmGroupMessages(), setPmsOptions(), me.SetPmGroup())
+	meG.POST("/pms/groups/:group/messages", me.NewPmGroupMessage(), me.SetPmGroup())
+	meG.POST("/pms/groups/:group/read", me.ReadPmGroup(), me.SetPmGroup())
+	meG.POST("/pms/groups/:group/members/:target", me.AddPmGroupMember(), me.SetPmGroup())
+	meG.DELETE("/pms/groups/:group/members/:target", me.RemovePmGroupMember(), me.SetPmGroup())
+	meG.PUT("/pms/groups/:group/admins/:target", me.GrantPmGroupAdmin(), me.SetPmGroup())
+	meG.DELETE("/pms/groups/:group/admins/:target", me.RevokePmGroupAdmin(), me.SetPmGroup())
 	// uses setPmsOptions middleware
 	meG.GET("/pms/:other", me.Conversation(), setPmsOptions())
 	meG.POST("/pms/:other", me.NewPm())
 	meG.DELETE("/pms/:other", me.DeleteConversation())
+	meG.POST("/pms/:other/read", me.ReadConversation())
 	// requests below uses the user.SetPm() middleware to refer to the requested pm
 	meG.GET("/pms/:other/:pmid", me.Pm(), me.SetPm())
-	// Disabled. At the moment pms' edit is not supported
-	//meG.PUT("/pms/:other/:pmid", me.EditPm(), me.SetPm())
+	meG.GET("/pms/:other/:pmid/revisions", me.PmRevisions(), me.SetPm())
+	meG.PUT("/pms/:other/:pmid", me.EditPm(), me.SetPm())
 	meG.DELETE("/pms/:other/:pmid", me.DeletePm(), me.SetPm())
+	meG.POST("/pms/:other/:pmid/read", me.ReadPm(), me.SetPm())
 
 	// uses setPostlist middleware
 	meG.GET("/posts", me.Posts(), setPostlist())
+	meG.GET("/pinned", me.Pinned())
 	meG.POST("/posts", me.NewPost())
+	// drafts and scheduled posts: requests with the draft parameter use the me.SetDraft() middleware
+	meG.GET("/drafts", me.Drafts())
+	meG.POST("/drafts", me.NewDraft())
+	meG.GET("/drafts/:draft", me.Draft(), me.SetDraft())
+	meG.PUT("/drafts/:draft", me.EditDraft(), me.SetDraft())
+	meG.DELETE("/drafts/:draft", me.DeleteDraft(), me.SetDraft())
+	meG.POST("/drafts/:draft/publish", me.PublishDraft(), me.SetDraft())
 	// requests below uses the user.SetPost() middleware to refer to the requested post
 	meG.GET("/posts/:pid", me.Post(), me.SetPost())
 	meG.PUT("/posts/:pid", me.EditPost(), me.SetPost())
 	meG.DELETE("/posts/:pid", me.DeletePost(), me.SetPost())
+	meG.GET("/posts/:pid/revisions", me.PostRevisions(), me.SetPost())
 	// Votes
 	meG.GET("/posts/:pid/votes", me.PostVotes(), me.SetPost())
 	// Vote can be used to add/edit/delete the vote, just changing the vote value
@@ -194,6 +254,14 @@ func Init(enableLog bool) *echo.Echo {
 	meG.DELETE("/posts/:pid/locks", me.DeletePostLock(), me.SetPost())
 	meG.POST("/posts/:pid/locks/:target", me.NewPostUserLock(), me.SetPost())
 	meG.DELETE("/posts/:pid/locks/:target", me.DeletePostUserLock(), me.SetPost())
+
+	meG.POST("/posts/:pid/pin", me.NewPostPin(), me.SetPost())
+	meG.DELETE("/posts/:pid/pin", me.DeletePostPin(), me.SetPost())
+	meG.POST("/posts/:pid/close", me.NewPostClosure(), me.SetPost())
+	meG.DELETE("/posts/:pid/close", me.DeletePostClosure(), me.SetPost())
+	meG.GET("/posts/:pid/subscription", me.PostSubscription(), me.SetPost())
+	meG.PUT("/posts/:pid/subscription", me.NewPostSubscription(), me.SetPost())
+	meG.DELETE("/posts/:pid/subscription", me.DeletePostSubscription(), me.SetPost())
 	// uses setCommentList middleware
 	meG.GET("/posts/:pid/comments", me.PostComments(), me.SetPost(), setCommentList())
 	meG.POST("/posts/:pid/comments", me.NewPostComment(), me.SetPost())
@@ -201,10 +269,35 @@ func Init(enableLog bool) *echo.Echo {
 	meG.GET("/posts/:pid/comments/:cid", me.PostComment(), me.SetPost(), me.SetComment())
 	meG.PUT("/posts/:pid/comments/:cid", me.EditPostComment(), me.SetPost(), me.SetComment())
 	meG.DELETE("/posts/:pid/comments/:cid", me.DeletePostComment(), me.SetPost(), me.SetComment())
+	meG.GET("/posts/:pid/comments/:cid/revisions", me.PostCommentRevisions(), me.SetPost(), me.SetComment())
 	// Votes
 	meG.GET("/posts/:pid/comments/:cid/votes", me.PostCommentVotes(), me.SetPost(), me.SetComment())
 	meG.POST("/posts/:pid/comments/:cid/votes", me.NewPostCommentVote(), me.SetPost(), me.SetComment())
 
+	// requests below uses the me.SetApp() middleware to refer to the requested application of the user
+	meG.GET("/apps/:app/webhooks", me.Webhooks(), me.SetApp())
+	meG.POST("/apps/:app/webhooks", me.NewWebhook(), me.SetApp())
+	meG.DELETE("/apps/:app/webhooks/:wid", me.DeleteWebhook(), me.SetApp(), me.SetWebhook())
+	meG.GET("/apps/:app/webhooks/:wid/deliveries", me.WebhookDeliveries(), me.SetApp(), me.SetWebhook())
+
+	/**************************************************************************
+	* ROUTE /interests/:value
+	* Authorization required
+	***************************************************************************/
+	interestsG := basePath.Group("/interests")
+	interestsG.Use(authorization())
+	// uses setUsersOptions middleware
+	interestsG.GET("/:value/users", interest.Users(), setUsersOptions())
+
+	/**************************************************************************
+	* ROUTE /search
+	* Authorization required
+	***************************************************************************/
+	searchG := basePath.Group("/search")
+	searchG.Use(authorization())
+	// uses setSearchOptions middleware
+	searchG.GET("", search.Search(), setSearchOptions())
+
 	/**************************************************************************
 	* ROUTE /projects/:id
 	* Authorization required
@@ -213,15 +306,36 @@ func Init(enableLog bool) *echo.Echo {
 	projectG.Use(authorization())
 	projectG.Use(project.SetProject())
 	projectG.GET("/:id", project.Info())
+	projectG.PATCH("/:id", project.Update())
+	projectG.DELETE("/:id", project.Delete())
+	projectG.PUT("/:id/owner/:target", project.TransferOwnership())
 	projectG.GET("/:id/members", project.Members())
+	projectG.GET("/:id/members/export", project.MembersExport())
+	projectG.DELETE("/:id/members/:target", project.Kick())
+	projectG.PUT("/:id/members/:target/role", project.SetRole())
+	projectG.GET("/:id/roles", project.Roles())
+	// membership workflow
+	projectG.POST("/:id/join", project.Join())
+	projectG.POST("/:id/leave", project.Leave())
+	projectG.GET("/:id/invites", project.Invites())
+	projectG.POST("/:id/invites/:target", project.Invite())
+	projectG.GET("/:id/requests", project.JoinRequests())
+	projectG.POST("/:id/requests/:target", project.ApproveJoinRequest())
+	projectG.DELETE("/:id/requests/:target", project.DenyJoinRequest())
 	projectG.GET("/:id/followers", project.Followers())
+	projectG.GET("/:id/followers/export", project.FollowersExport())
+	// uses setProjectStatsOptions middleware
+	projectG.GET("/:id/stats", project.Stats(), setProjectStatsOptions())
 	// uses setPostlist middleware
 	projectG.GET("/:id/posts", project.Posts(), setPostlist())
+	projectG.GET("/:id/pinned", project.Pinned())
 	projectG.POST("/:id/posts", project.NewPost())
+	projectG.POST("/:id/drafts", project.NewDraft())
 	// requests below uses the project.SetPost() middleware to refer to the requested post
 	projectG.GET("/:id/posts/:pid", project.Post(), project.SetPost())
 	projectG.PUT("/:id/posts/:pid", project.EditPost(), project.SetPost())
 	projectG.DELETE("/:id/posts/:pid", project.DeletePost(), project.SetPost())
+	projectG.GET("/:id/posts/:pid/revisions", project.PostRevisions(), project.SetPost())
 	// Votes
 	projectG.GET("/:id/posts/:pid/votes", project.PostVotes(), project.SetPost())
 	// Vote can be used to add/edit/delete the vote, just changing the vote value
@@ -240,6 +354,14 @@ func Init(enableLog bool) *echo.Echo {
 	projectG.DELETE("/:id/posts/:pid/locks", project.DeletePostLock(), project.SetPost())
 	projectG.POST("/:id/posts/:pid/locks/:target", project.NewPostUserLock(), project.SetPost())
 	projectG.DELETE("/:id/posts/:pid/locks/:target", project.DeletePostUserLock(), project.SetPost())
+
+	projectG.POST("/:id/posts/:pid/pin", project.NewPostPin(), project.SetPost())
+	projectG.DELETE("/:id/posts/:pid/pin", project.DeletePostPin(), project.SetPost())
+	projectG.POST("/:id/posts/:pid/close", project.NewPostClosure(), project.SetPost())
+	projectG.DELETE("/:id/posts/:pid/close", project.DeletePostClosure(), project.SetPost())
+	projectG.GET("/:id/posts/:pid/subscription", project.PostSubscription(), project.SetPost())
+	projectG.PUT("/:id/posts/:pid/subscription", project.NewPostSubscription(), project.SetPost())
+	projectG.DELETE("/:id/posts/:pid/subscription", project.DeletePostSubscription(), project.SetPost())
 	// uses setCommentList middleware
 	projectG.GET("/:id/posts/:pid/comments", project.PostComments(), project.SetPost(), setCommentList())
 	projectG.POST("/:id/posts/:pid/comments", project.NewPostComment(), project.SetPost())
@@ -247,10 +369,21 @@ func Init(enableLog bool) *echo.Echo {
 	projectG.GET("/:id/posts/:pid/comments/:cid", project.PostComment(), project.SetPost(), project.SetComment())
 	projectG.PUT("/:id/posts/:pid/comments/:cid", project.EditPostComment(), project.SetPost(), project.SetComment())
 	projectG.DELETE("/:id/posts/:pid/comments/:cid", project.DeletePostComment(), project.SetPost(), project.SetComment())
+	projectG.GET("/:id/posts/:pid/comments/:cid/revisions", project.PostCommentRevisions(), project.SetPost(), project.SetComment())
 	// Votes
 	projectG.GET("/:id/posts/:pid/comments/:cid/votes", project.PostCommentVotes(), project.SetPost(), project.SetComment())
 	projectG.POST("/:id/posts/:pid/comments/:cid/votes", project.NewPostCommentVote(), project.SetPost(), project.SetComment())
 
+	/**************************************************************************
+	* Stream API
+	* ROUTE /stream
+	* Authorization required
+	***************************************************************************/
+	streamG := basePath.Group("/stream")
+	streamG.Use(authorization())
+	// multiplexed stream: live updates of every topic the client subscribes to
+	streamG.GET("", stream.Subscriptions())
+
 	/**************************************************************************
 	* Stream API
 	* ROUTE /stream/me
@@ -260,13 +393,10 @@ func Init(enableLog bool) *echo.Echo {
 	s.Use(authorization())
 	// notification for current logged in user
 	s.GET("/notifications", stream.Notifications())
-	// TODO
-	// /stream/users group
-	//streamUsers := s.Group("/users/:id")
-	// live update of current open profile
-	//streamUsers.GET("/", stream.UserPosts())
-	// live update of comments on current post
-	//streamUsers.GET("/:pid/comments", stream.UserPostComments())
+	// new pms, typing indicators and read receipts of the conversation with other
+	s.GET("/pms/:other", stream.Conversation())
+	// online status of the followed users
+	s.GET("/presence", stream.Presence())
 
 	return e
 }
@@ -0,0 +1,183 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+)
+
+const (
+	// writeWait is the time allowed to write a message to the peer
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed to read the next pong message from the peer.
+	// A stream that stays idle longer than pongWait is closed
+	pongWait = 60 * time.Second
+	// pingPeriod is the period used to send pings to the peer. Must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize is the maximum size of a message sent by the peer
+	maxMessageSize = 4096
+	// sendBufferSize is the number of messages queued for a stream.
+	// When the buffer is full the peer is considered a slow consumer and it's disconnected
+	sendBufferSize = 64
+)
+
+// upgrader upgrades the HTTP connections to websocket connections.
+// Every origin is allowed, since the authorization is done via access token
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// client is a single stream opened by a user
+type client struct {
+	ws   *websocket.Conn
+	user uint64
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+	// closeCode and closeText are sent to the peer in the close message
+	closeCode int
+	closeText string
+	// channels is the set of database channels the client is subscribed to.
+	// It's guarded by the hub mutex
+	channels map[string]struct{}
+}
+
+// newClient creates a new client for the user that owns the websocket connection
+func newClient(ws *websocket.Conn, user uint64) *client {
+	return &client{
+		ws:        ws,
+		user:      user,
+		send:      make(chan []byte, sendBufferSize),
+		done:      make(chan struct{}),
+		closeCode: websocket.CloseNormalClosure,
+	}
+}
+
+// enqueue adds the message to the send buffer of the client.
+// If the buffer is full, the client is disconnected.
+func (c *client) enqueue(message []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- message:
+		return true
+	default:
+		log.Warnf("Stream of user %d is too slow, disconnecting", c.user)
+		c.close(websocket.CloseTryAgainLater, "slow consumer")
+		return false
+	}
+}
+
+// close asks the write pump to send the close message and to close the connection.
+// Only the first call has effect
+func (c *client) close(code int, text string) {
+	c.once.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// writePump writes the queued messages and the pings to the peer.
+// It returns when the client is closed or when a write fails
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.ws.Close()
+	}()
+
+	for {
+		select {
+		case message := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeText),
+				time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// readPump reads the messages sent by the peer and passes them to handle, if not nil.
+// The read deadline is extended every time a pong or a message is received.
+// It returns when the client is closed or when a read fails
+func (c *client) readPump(handle func(message []byte)) {
+	defer c.close(websocket.CloseNormalClosure, "")
+
+	c.ws.SetReadLimit(maxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetPongHandler(func(string) error {
+		// an open stream keeps the user online
+		c.touch()
+		return c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, message, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		if handle != nil {
+			handle(message)
+		}
+	}
+}
+
+// touch records the activity of the user of the stream
+func (c *client) touch() {
+	if err := nerdz.TouchUser(c.user); err != nil {
+		log.Errorf("Unable to record the activity of user %d: %s", c.user, err.Error())
+	}
+}
+
+// run starts the pumps of the client and blocks until the stream is closed.
+// The client is unregistered from the hub before returning
+func (c *client) run(handle func(message []byte)) {
+	defer streams.unregister(c)
+
+	go c.readPump(handle)
+	c.writePump()
+}
@@ -0,0 +1,319 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/gommon/log"
+	"github.com/lib/pq"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+)
+
+const (
+	// listenerMinReconnect and listenerMaxReconnect are the bounds of the interval
+	// between the attempts to reconnect the listener to the database
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingPeriod is the period of the checks of the listener connection,
+	// when no notifications are received
+	listenerPingPeriod = 90 * time.Second
+)
+
+var (
+	// errShuttingDown is returned when a new stream is opened while the server is draining
+	errShuttingDown = errors.New("the server is shutting down")
+	// errTooManyStreams is returned when the user reached the maximum number of open streams
+	errTooManyStreams = errors.New("too many open streams for the current user")
+)
+
+// hub keeps track of every open stream and dispatches the notifications
+// received on the database channels to the subscribed streams.
+// A database channel is listened only once, no matter how many
+// streams are subscribed to it, and only while some stream is subscribed to it.
+type hub struct {
+	mu          sync.Mutex
+	listener    *pq.Listener
+	stop        chan struct{}
+	listening   map[string]bool
+	queue       notificationQueue
+	subscribers map[string]map[*client]func(payload string)
+	users       map[uint64]map[*client]struct{}
+	closing     bool
+	wg          sync.WaitGroup
+}
+
+// notificationQueue is the unbounded FIFO queue of the received notifications.
+// The listener never blocks on it, thus it's always free to receive the replies
+// to the Listen and Unlisten calls, while the notifications are dispatched in order
+type notificationQueue struct {
+	mu            sync.Mutex
+	notifications []*pq.Notification
+	ready         chan struct{}
+}
+
+// push appends the notification to the queue and wakes up the dispatcher
+func (q *notificationQueue) push(notification *pq.Notification) {
+	q.mu.Lock()
+	q.notifications = append(q.notifications, notification)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// drain removes and returns every queued notification, from the oldest
+func (q *notificationQueue) drain() []*pq.Notification {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	notifications := q.notifications
+	q.notifications = nil
+	return notifications
+}
+
+// streams is the hub shared by every stream endpoint
+var streams = &hub{
+	listening:   make(map[string]bool),
+	queue:       notificationQueue{ready: make(chan struct{}, 1)},
+	subscribers: make(map[string]map[*client]func(payload string)),
+	users:       make(map[uint64]map[*client]struct{}),
+}
+
+// register adds the client to the hub, if the user has not reached
+// the maximum number of open streams and the hub is not shutting down
+func (h *hub) register(c *client) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closing {
+		return errShuttingDown
+	}
+
+	if len(h.users[c.user]) >= nerdz.Configuration.StreamsPerUser {
+		return errTooManyStreams
+	}
+
+	if h.users[c.user] == nil {
+		h.users[c.user] = make(map[*client]struct{})
+	}
+	h.users[c.user][c] = struct{}{}
+	h.wg.Add(1)
+	return nil
+}
+
+// unregister removes the client from the hub and from every channel it was subscribed to
+func (h *hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.users[c.user][c]; !ok {
+		return
+	}
+
+	for channel := range c.channels {
+		h.removeSubscriber(channel, c)
+	}
+	c.channels = nil
+
+	delete(h.users[c.user], c)
+	if len(h.users[c.user]) == 0 {
+		delete(h.users, c.user)
+	}
+	h.wg.Done()
+}
+
+// subscribe subscribes the client to the database channel: every payload received
+// on the channel is passed to deliver. The first subscription to a channel starts listening on it
+func (h *hub) subscribe(channel string, c *client, deliver func(payload string)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closing {
+		return errShuttingDown
+	}
+
+	if h.listener == nil {
+		connectionString, err := nerdz.Configuration.ConnectionString()
+		if err != nil {
+			return err
+		}
+		h.listener = pq.NewListener(connectionString, listenerMinReconnect, listenerMaxReconnect,
+			func(_ pq.ListenerEventType, err error) {
+				if err != nil {
+					log.Errorf("(stream) Listener error: %s", err)
+				}
+			})
+		h.stop = make(chan struct{})
+		go h.listen(h.listener)
+		go h.dispatchQueued(h.stop)
+	}
+
+	if !h.listening[channel] {
+		if err := h.listener.Listen(channel); err != nil {
+			return err
+		}
+		h.listening[channel] = true
+	}
+
+	if h.subscribers[channel] == nil {
+		h.subscribers[channel] = make(map[*client]func(payload string))
+	}
+	h.subscribers[channel][c] = deliver
+	if c.channels == nil {
+		c.channels = make(map[string]struct{})
+	}
+	c.channels[channel] = struct{}{}
+	return nil
+}
+
+// unsubscribe removes the client from the subscribers of the database channel
+func (h *hub) unsubscribe(channel string, c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeSubscriber(channel, c)
+	delete(c.channels, channel)
+}
+
+// removeSubscriber removes the client from the subscribers of the database channel.
+// The last subscriber removed stops listening on the channel. Must be called with the lock held
+func (h *hub) removeSubscriber(channel string, c *client) {
+	delete(h.subscribers[channel], c)
+	if len(h.subscribers[channel]) > 0 {
+		return
+	}
+	delete(h.subscribers, channel)
+	if h.listening[channel] {
+		if err := h.listener.Unlisten(channel); err != nil {
+			log.Errorf("(stream) Unable to stop listening on channel %s: %s", channel, err)
+		}
+		delete(h.listening, channel)
+	}
+}
+
+// listen receives the notifications of the listener and queues them,
+// checking the connection when no notifications are received.
+// It returns when the listener is closed
+func (h *hub) listen(listener *pq.Listener) {
+	for {
+		select {
+		case notification, ok := <-listener.Notify:
+			if !ok {
+				return
+			}
+			// a nil notification is sent when the connection has been reestablished
+			if notification != nil {
+				h.queue.push(notification)
+			}
+		case <-time.After(listenerPingPeriod):
+			go func() {
+				if err := listener.Ping(); err != nil {
+					log.Errorf("(stream) Listener ping failed: %s", err)
+				}
+			}()
+		}
+	}
+}
+
+// dispatchQueued dispatches the queued notifications, in the order they have been received,
+// until stop is closed
+func (h *hub) dispatchQueued(stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-h.queue.ready:
+		}
+		for _, notification := range h.queue.drain() {
+			h.dispatch(notification.Channel, notification.Extra)
+		}
+	}
+}
+
+// closeListener closes the listener and stops the dispatch of the notifications
+func (h *hub) closeListener() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.listener == nil {
+		return
+	}
+	if err := h.listener.Close(); err != nil {
+		log.Errorf("(stream) Unable to close the listener: %s", err)
+	}
+	close(h.stop)
+	h.listener = nil
+	h.listening = make(map[string]bool)
+}
+
+// dispatch delivers every payload to the clients subscribed to the channel
+func (h *hub) dispatch(channel string, payload ...string) {
+	h.mu.Lock()
+	var delivers []func(payload string)
+	for _, deliver := range h.subscribers[channel] {
+		delivers = append(delivers, deliver)
+	}
+	h.mu.Unlock()
+
+	for _, deliver := range delivers {
+		for _, message := range payload {
+			deliver(message)
+		}
+	}
+}
+
+// Shutdown closes every open stream, with a going away close message,
+// and waits until every stream has been drained or the context expires.
+// New streams are refused once Shutdown has been called.
+// The database listener is closed before returning
+func Shutdown(ctx context.Context) error {
+	defer streams.closeListener()
+
+	streams.mu.Lock()
+	streams.closing = true
+	var clients []*client
+	for _, userClients := range streams.users {
+		for c := range userClients {
+			clients = append(clients, c)
+		}
+	}
+	streams.mu.Unlock()
+
+	log.Infof("Closing %d open streams", len(clients))
+	for _, c := range clients {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		streams.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -19,13 +19,12 @@ package stream
 
 import (
 	"net/http"
-	"strconv"
 
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 	"github.com/nerdzeu/nerdz-api/nerdz"
 	"github.com/openshift/osin"
-	"golang.org/x/net/websocket"
 )
 
 // swagger:route GET /stream/me/notifications stream me notifications GetStreamMeNotifications
@@ -44,29 +43,32 @@ func Notifications() echo.HandlerFunc {
 		if accessData == nil {
 			return c.String(http.StatusInternalServerError, "Invalid authorization")
 		}
+		userID := accessData.UserData.(uint64)
 
-		websocket.Server{Handler: websocket.Handler(func(ws *websocket.Conn) {
-			// Listen from notification sent on DB channel u<ID>
-			if err := nerdz.Db().Listen("u"+strconv.Itoa(int(accessData.UserData.(uint64))), func(payload ...string) {
-				if len(payload) == 1 {
-					if websocket.Message.Send(ws, payload[0]) != nil {
-						return
-					}
-				}
-			}); err != nil {
-				log.Errorf("Error listening to u%d: %s", accessData.UserData.(uint64), err.Error())
-				return
-			}
+		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			// the upgrader already replied to the client
+			return nil
+		}
+
+		client := newClient(ws, userID)
+		if err = streams.register(client); err != nil {
+			client.close(websocket.ClosePolicyViolation, err.Error())
+			client.writePump()
+			return nil
+		}
+
+		// Listen from notification sent on DB channel u<ID>
+		channel := nerdz.NotificationsChannel(userID)
+		if err = streams.subscribe(channel, client, func(payload string) {
+			client.enqueue([]byte(payload))
+		}); err != nil {
+			log.Errorf("Error listening to %s: %s", channel, err.Error())
+			client.close(websocket.CloseInternalServerErr, "unable to listen to notifications")
+		}
 
-			// try to read from client (we don't expect a message) to prevent websocket closing
-			for {
-				var m string
-				if websocket.Message.Receive(ws, &m) != nil {
-					// If here, client closed the connection
-					break
-				}
-			}
-		})}.ServeHTTP(c.Response(), c.Request())
+		// we don't expect messages from the client: we read only to handle pongs and closing
+		client.run(nil)
 		return nil
 	}
 }
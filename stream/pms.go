@@ -0,0 +1,154 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// conversationEvent is the data of the events of a conversation.
+// Pm is present only in the events of type nerdz.PmEventNew and nerdz.PmEventEdit
+type conversationEvent struct {
+	Type string      `json:"type"`
+	From uint64      `json:"from"`
+	To   uint64      `json:"to"`
+	Time time.Time   `json:"time"`
+	Pmid uint64      `json:"pmid,omitempty"`
+	Pm   *nerdz.PmTO `json:"pm,omitempty"`
+}
+
+// conversationTopic returns the topic of the conversation between me and other.
+// The subscribers can send typing and read frames, that require the pms:write scope
+func conversationTopic(name string, me, other *nerdz.User, c echo.Context) *topic {
+	return &topic{
+		name:    name,
+		channel: nerdz.PmsChannel(me.ID(), other.ID()),
+		render: func(payload string) (interface{}, error) {
+			var event nerdz.PmEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				return nil, err
+			}
+
+			data := &conversationEvent{
+				Type: event.Type,
+				From: event.From,
+				To:   event.To,
+				Time: event.Time,
+				Pmid: event.Pmid,
+			}
+			switch event.Type {
+			case nerdz.PmEventNew, nerdz.PmEventEdit:
+				pm, err := nerdz.NewPm(event.Pmid)
+				if err != nil {
+					return nil, err
+				}
+				data.Pm = pm.GetTO(me)
+			case nerdz.PmEventTyping:
+				// the user that's typing doesn't need to know it
+				if event.From == me.ID() {
+					return nil, errors.New("own typing event")
+				}
+			}
+			return data, nil
+		},
+		handle: func(request *frame) error {
+			if err := requireScope("pms:write", c); err != nil {
+				return err
+			}
+			switch request.Type {
+			case frameTyping:
+				return me.Typing(other.ID())
+			case frameRead:
+				return me.ReadConversation(other.ID())
+			}
+			return errInvalidFrameType
+		},
+	}
+}
+
+// swagger:route GET /stream/me/pms/{other} stream me pms GetStreamMePms
+//
+// # Conversation is the route for the stream of the conversation of the current user with the other user.
+// This is a WEBSOCKET endpoint.
+// The new and edited pms, the typing indicators and the read receipts are sent in "event" frames.
+// The client can send the frames
+//
+//	{"type": "typing", "id": "1"}
+//	{"type": "read", "id": "2"}
+//
+// to notify that the user is typing and to mark the conversation as read.
+// Every client frame is acknowledged by an "ack" frame with the same id.
+//
+//	Produces:
+//	- application/json
+//
+//	Security:
+//		oauth: pms:read
+func Conversation() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !rest.IsGranted("pms:read", c) {
+			return rest.InvalidScopeResponse("pms:read", c)
+		}
+
+		other, err := rest.User("other", c)
+		if err != nil {
+			return err
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		t := conversationTopic("pm:"+strconv.FormatUint(other.ID(), 10), me, other, c)
+
+		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			// the upgrader already replied to the client
+			return nil
+		}
+
+		client := newClient(ws, me.ID())
+		if err = streams.register(client); err != nil {
+			client.close(websocket.ClosePolicyViolation, err.Error())
+			client.writePump()
+			return nil
+		}
+
+		if err = t.subscribe(client); err != nil {
+			log.Errorf("Error listening to %s: %s", t.channel, err.Error())
+			client.close(websocket.CloseInternalServerErr, "unable to listen to the conversation")
+		}
+
+		client.run(func(message []byte) {
+			var request frame
+			if err := json.Unmarshal(message, &request); err != nil {
+				client.ack(&request, errors.New("invalid frame: "+err.Error()))
+				return
+			}
+			request.Topic = t.name
+			client.ack(&request, t.handle(&request))
+		})
+		return nil
+	}
+}
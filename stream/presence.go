@@ -0,0 +1,115 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+	"github.com/nerdzeu/nerdz-api/utils"
+)
+
+// presenceTopic returns the topic of the presence of the users followed by me.
+// The presence is computed when the event is received, thus the users that
+// hide their online status are never seen online. The followed users are loaded
+// at the subscription and reloaded when me follows or unfollows a user
+func presenceTopic(me *nerdz.User) *topic {
+	var mu sync.Mutex
+	var following []uint64
+	return &topic{
+		name:    "presence",
+		channel: nerdz.PresenceChannel,
+		render: func(payload string) (interface{}, error) {
+			id, err := parseID(payload)
+			if err != nil {
+				return nil, err
+			}
+			mu.Lock()
+			followed := utils.InSlice(id, following)
+			mu.Unlock()
+			if !followed {
+				return nil, errors.New("user not followed")
+			}
+			user, err := nerdz.NewUser(id)
+			if err != nil {
+				return nil, err
+			}
+			if !me.CanSee(user) {
+				return nil, errCantSee
+			}
+			return user.Presence().GetTO(), nil
+		},
+		refresh: func() {
+			ids := me.NumericUserFollowing()
+			mu.Lock()
+			following = ids
+			mu.Unlock()
+		},
+		refreshChannel: nerdz.FollowingChannel(me.ID()),
+	}
+}
+
+// swagger:route GET /stream/me/presence stream me presence GetStreamMePresence
+//
+// # Presence is the route for the stream of the online status of the users followed by the current user.
+// This is a WEBSOCKET endpoint.
+// When a followed user comes online or goes offline, its presence is sent in an "event" frame.
+// The users that hide their online status are never seen online.
+//
+//	Produces:
+//	- application/json
+//
+//	Security:
+//		oauth: following:read
+func Presence() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !rest.IsGranted("following:read", c) {
+			return rest.InvalidScopeResponse("following:read", c)
+		}
+
+		me := c.Get("me").(*nerdz.User)
+		t := presenceTopic(me)
+
+		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			// the upgrader already replied to the client
+			return nil
+		}
+
+		client := newClient(ws, me.ID())
+		if err = streams.register(client); err != nil {
+			client.close(websocket.ClosePolicyViolation, err.Error())
+			client.writePump()
+			return nil
+		}
+
+		if err = t.subscribe(client); err != nil {
+			log.Errorf("Error listening to %s: %s", t.channel, err.Error())
+			client.close(websocket.CloseInternalServerErr, "unable to listen to the presence")
+		}
+
+		// we don't expect messages from the client: we read only to handle pongs and closing
+		client.run(nil)
+		return nil
+	}
+}
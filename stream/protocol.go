@@ -0,0 +1,79 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Types of the frames exchanged on the multiplexed stream
+const (
+	// frameSubscribe is sent by the client to subscribe to a topic
+	frameSubscribe = "subscribe"
+	// frameUnsubscribe is sent by the client to unsubscribe from a topic
+	frameUnsubscribe = "unsubscribe"
+	// frameTyping is sent by the client to notify that the user is typing a pm
+	frameTyping = "typing"
+	// frameRead is sent by the client to mark the pms of a conversation as read
+	frameRead = "read"
+	// frameAck is sent by the server in reply to every client frame
+	frameAck = "ack"
+	// frameEvent is sent by the server for every new content of a subscribed topic
+	frameEvent = "event"
+)
+
+// errInvalidFrameType is returned when the client sends a frame not supported by the stream
+var errInvalidFrameType = errors.New("invalid frame type")
+
+// frame is the JSON message exchanged on the multiplexed stream.
+// The ID of a client frame is copied into the ack frame, so the client can match
+// the replies to its requests. A not empty Error in the ack frame means that
+// the request failed.
+type frame struct {
+	Type  string      `json:"type"`
+	ID    string      `json:"id,omitempty"`
+	Topic string      `json:"topic,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// sendFrame encodes the frame and adds it to the send buffer of the client
+func (c *client) sendFrame(f *frame) {
+	message, err := json.Marshal(f)
+	if err != nil {
+		log.Errorf("Unable to encode %s frame: %s", f.Type, err.Error())
+		return
+	}
+	c.enqueue(message)
+}
+
+// ack replies to the client frame, reporting the error if not nil
+func (c *client) ack(request *frame, err error) {
+	reply := &frame{
+		Type:  frameAck,
+		ID:    request.ID,
+		Topic: request.Topic,
+	}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	c.sendFrame(reply)
+}
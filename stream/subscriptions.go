@@ -0,0 +1,125 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+)
+
+// swagger:route GET /stream stream Subscriptions
+//
+// # Subscriptions is the route for the multiplexed stream of the current user.
+// This is a WEBSOCKET endpoint.
+// The client subscribes to the topics it's interested in, sending frames like
+//
+//	{"type": "subscribe", "id": "1", "topic": "user:1:posts"}
+//	{"type": "unsubscribe", "id": "2", "topic": "user:1:posts"}
+//
+// Every frame is acknowledged by an "ack" frame with the same id, containing
+// the error if the request failed. The new contents of the subscribed topics
+// are sent in "event" frames.
+// The subscribers of a pm topic can send "typing" and "read" frames too, to
+// notify that the user is typing and to mark the conversation as read.
+// Every topic requires the read scope of the content it streams.
+//
+//	Produces:
+//	- application/json
+//
+//	Security:
+//		oauth: base:read
+func Subscriptions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		me := c.Get("me").(*nerdz.User)
+
+		ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			// the upgrader already replied to the client
+			return nil
+		}
+
+		client := newClient(ws, me.ID())
+		if err = streams.register(client); err != nil {
+			client.close(websocket.ClosePolicyViolation, err.Error())
+			client.writePump()
+			return nil
+		}
+
+		// topics contains the subscribed topics, indexed by name.
+		// It's used only by the read pump, hence it needs no lock
+		topics := make(map[string]*topic)
+
+		client.run(func(message []byte) {
+			var request frame
+			if err := json.Unmarshal(message, &request); err != nil {
+				client.ack(&request, errors.New("invalid frame: "+err.Error()))
+				return
+			}
+
+			switch request.Type {
+			case frameSubscribe:
+				if _, ok := topics[request.Topic]; ok {
+					client.ack(&request, nil)
+					return
+				}
+
+				t, err := resolveTopic(request.Topic, c)
+				if err != nil {
+					client.ack(&request, err)
+					return
+				}
+
+				if err = t.subscribe(client); err != nil {
+					log.Errorf("Error listening to %s: %s", t.channel, err.Error())
+					client.ack(&request, errors.New("unable to subscribe to the topic"))
+					return
+				}
+				topics[t.name] = t
+				client.ack(&request, nil)
+
+			case frameUnsubscribe:
+				t, ok := topics[request.Topic]
+				if !ok {
+					client.ack(&request, errNotSubscribed)
+					return
+				}
+				t.unsubscribe(client)
+				delete(topics, request.Topic)
+				client.ack(&request, nil)
+
+			default:
+				t, ok := topics[request.Topic]
+				if !ok {
+					client.ack(&request, errNotSubscribed)
+					return
+				}
+				if t.handle == nil {
+					client.ack(&request, errInvalidFrameType)
+					return
+				}
+				client.ack(&request, t.handle(&request))
+			}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/nerdzeu/nerdz-api/nerdz"
+	"github.com/nerdzeu/nerdz-api/rest"
+)
+
+// topic is a subscription target of the multiplexed stream:
+// it binds the name requested by the client to the database channel
+// where the events are published
+type topic struct {
+	name    string
+	channel string
+	// render converts the payload received on the channel into the data of the event.
+	// If it returns an error, the event is not sent
+	render func(payload string) (interface{}, error)
+	// handle, if not nil, handles the frames of the topic sent by the client
+	// that are not subscriptions requests
+	handle func(request *frame) error
+	// refresh, if not nil, updates the state used by render. It's called at the subscription
+	// and for every payload received on the refreshChannel
+	refresh        func()
+	refreshChannel string
+}
+
+var (
+	errInvalidTopic  = errors.New("invalid topic")
+	errCantSee       = errors.New("you can't see the content of the topic")
+	errNotSubscribed = errors.New("not subscribed to the topic")
+)
+
+// resolveTopic parses the name of the topic and returns the topic if the user
+// of the context has the scope required and is allowed to see its content.
+// The supported topics are:
+//
+//	notifications
+//	presence
+//	user:<id>:posts
+//	project:<id>:posts
+//	post:<hpid>:comments
+//	project_post:<hpid>:comments
+//	pm:<other>
+func resolveTopic(name string, c echo.Context) (*topic, error) {
+	me := c.Get("me").(*nerdz.User)
+	parts := strings.Split(name, ":")
+
+	var id uint64
+	var err error
+	switch len(parts) {
+	case 1:
+		if parts[0] != "notifications" && parts[0] != "presence" {
+			return nil, errInvalidTopic
+		}
+	case 2, 3:
+		if id, err = strconv.ParseUint(parts[1], 10, 64); err != nil || id == 0 {
+			return nil, errInvalidTopic
+		}
+	default:
+		return nil, errInvalidTopic
+	}
+
+	switch {
+	case name == "notifications":
+		if err = requireScope("notifications:read", c); err != nil {
+			return nil, err
+		}
+		return &topic{
+			name:    name,
+			channel: nerdz.NotificationsChannel(me.ID()),
+			render: func(payload string) (interface{}, error) {
+				if json.Valid([]byte(payload)) {
+					return json.RawMessage(payload), nil
+				}
+				return payload, nil
+			},
+		}, nil
+
+	case name == "presence":
+		if err = requireScope("following:read", c); err != nil {
+			return nil, err
+		}
+		return presenceTopic(me), nil
+
+	case len(parts) == 3 && parts[0] == "user" && parts[2] == "posts":
+		if err = requireScope("profile_messages:read", c); err != nil {
+			return nil, err
+		}
+		var user *nerdz.User
+		if user, err = nerdz.NewUser(id); err != nil {
+			return nil, err
+		}
+		if !me.CanSee(user) {
+			return nil, errCantSee
+		}
+		return &topic{
+			name:    name,
+			channel: nerdz.UserPostsChannel(user.ID()),
+			render: func(payload string) (interface{}, error) {
+				id, err := parseID(payload)
+				if err != nil {
+					return nil, err
+				}
+				post, err := nerdz.NewUserPost(id)
+				if err != nil {
+					return nil, err
+				}
+				return post.GetTO(me), nil
+			},
+		}, nil
+
+	case len(parts) == 3 && parts[0] == "project" && parts[2] == "posts":
+		if err = requireScope("project_messages:read", c); err != nil {
+			return nil, err
+		}
+		var project *nerdz.Project
+		if project, err = nerdz.NewProject(id); err != nil {
+			return nil, err
+		}
+		if !me.CanSee(project) {
+			return nil, errCantSee
+		}
+		return &topic{
+			name:    name,
+			channel: nerdz.ProjectPostsChannel(project.ID()),
+			render: func(payload string) (interface{}, error) {
+				id, err := parseID(payload)
+				if err != nil {
+					return nil, err
+				}
+				post, err := nerdz.NewProjectPost(id)
+				if err != nil {
+					return nil, err
+				}
+				return post.GetTO(me), nil
+			},
+		}, nil
+
+	case len(parts) == 3 && parts[0] == "post" && parts[2] == "comments":
+		if err = requireScope("profile_comments:read", c); err != nil {
+			return nil, err
+		}
+		var post *nerdz.UserPost
+		if post, err = nerdz.NewUserPost(id); err != nil {
+			return nil, err
+		}
+		if board, ok := post.Reference().(*nerdz.User); !ok || !me.CanSee(board) {
+			return nil, errCantSee
+		}
+		return &topic{
+			name:    name,
+			channel: nerdz.UserPostCommentsChannel(post.ID()),
+			render: func(payload string) (interface{}, error) {
+				id, err := parseID(payload)
+				if err != nil {
+					return nil, err
+				}
+				comment, err := nerdz.NewUserPostComment(id)
+				if err != nil {
+					return nil, err
+				}
+				return comment.GetTO(me), nil
+			},
+		}, nil
+
+	case len(parts) == 3 && parts[0] == "project_post" && parts[2] == "comments":
+		if err = requireScope("project_comments:read", c); err != nil {
+			return nil, err
+		}
+		var post *nerdz.ProjectPost
+		if post, err = nerdz.NewProjectPost(id); err != nil {
+			return nil, err
+		}
+		if board, ok := post.Reference().(*nerdz.Project); !ok || !me.CanSee(board) {
+			return nil, errCantSee
+		}
+		return &topic{
+			name:    name,
+			channel: nerdz.ProjectPostCommentsChannel(post.ID()),
+			render: func(payload string) (interface{}, error) {
+				id, err := parseID(payload)
+				if err != nil {
+					return nil, err
+				}
+				comment, err := nerdz.NewProjectPostComment(id)
+				if err != nil {
+					return nil, err
+				}
+				return comment.GetTO(me), nil
+			},
+		}, nil
+
+	case len(parts) == 2 && parts[0] == "pm":
+		if err = requireScope("pms:read", c); err != nil {
+			return nil, err
+		}
+		var other *nerdz.User
+		if other, err = nerdz.NewUser(id); err != nil {
+			return nil, err
+		}
+		return conversationTopic(name, me, other, c), nil
+	}
+
+	return nil, errInvalidTopic
+}
+
+// subscribe subscribes the client to the topic: every event is rendered and sent in an event frame
+func (t *topic) subscribe(c *client) error {
+	if t.refresh != nil {
+		// the refresh channel is listened before refreshing, thus no change is lost
+		if err := streams.subscribe(t.refreshChannel, c, func(string) { t.refresh() }); err != nil {
+			return err
+		}
+		t.refresh()
+	}
+	err := streams.subscribe(t.channel, c, func(payload string) {
+		data, err := t.render(payload)
+		if err != nil {
+			log.Debugf("Event of topic %s skipped: %s", t.name, err.Error())
+			return
+		}
+		c.sendFrame(&frame{Type: frameEvent, Topic: t.name, Data: data})
+	})
+	if err != nil && t.refresh != nil {
+		streams.unsubscribe(t.refreshChannel, c)
+	}
+	return err
+}
+
+// unsubscribe unsubscribes the client from the topic
+func (t *topic) unsubscribe(c *client) {
+	streams.unsubscribe(t.channel, c)
+	if t.refresh != nil {
+		streams.unsubscribe(t.refreshChannel, c)
+	}
+}
+
+// requireScope returns an error if the scope is not granted to the client of the context
+func requireScope(scope string, c echo.Context) error {
+	if !rest.IsGranted(scope, c) {
+		return errors.New("required scope (" + scope + ") is missing")
+	}
+	return nil
+}
+
+// parseID parses the ID sent as payload on a message channel
+func parseID(payload string) (uint64, error) {
+	id, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("invalid ID in payload")
+	}
+	return id, nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright (C) 2016-2020 Paolo Galeone <nessuno@nerdz.eu>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around the changes of a unified diff
+	diffContext = 3
+	// MaxDiffLines is the maximum number of lines of the texts compared by UnifiedDiff.
+	// The memory required by the diff grows with the product of the lines of the texts
+	MaxDiffLines = 1000
+)
+
+// diffLine is a line of a diff: kind is ' ' when the line is unchanged, '-' when
+// it has been removed and '+' when it has been added. from and to are the numbers
+// of the lines of the two texts that precede the line
+type diffLine struct {
+	kind     byte
+	text     string
+	from, to int
+}
+
+// UnifiedDiff returns the line by line unified diff between the from and the to texts,
+// labelled with fromName and toName. If the texts are equal, or one of them
+// has more than MaxDiffLines lines, the diff is empty
+func UnifiedDiff(from, to, fromName, toName string) string {
+	if from == to {
+		return ""
+	}
+	fromLines, toLines := strings.Split(from, "\n"), strings.Split(to, "\n")
+	if len(fromLines) > MaxDiffLines || len(toLines) > MaxDiffLines {
+		return ""
+	}
+	lines := diffLines(fromLines, toLines)
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(lines); {
+		// find the next change and extend the hunk until the changes are far enough
+		first := start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i-last <= 2*diffContext; i++ {
+			if lines[i].kind != ' ' {
+				last = i
+			}
+		}
+		begin, end := first-diffContext, last+diffContext+1
+		if begin < 0 {
+			begin = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		var fromCount, toCount int
+		for _, line := range lines[begin:end] {
+			if line.kind != '+' {
+				fromCount++
+			}
+			if line.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%s +%s @@\n",
+			hunkRange(lines[begin].from, fromCount), hunkRange(lines[begin].to, toCount))
+		for _, line := range lines[begin:end] {
+			diff.WriteByte(line.kind)
+			diff.WriteString(line.text)
+			diff.WriteByte('\n')
+		}
+		start = end
+	}
+	return diff.String()
+}
+
+// hunkRange formats the range of a hunk that follows the line preceding
+// and contains count lines, as GNU diff does
+func hunkRange(preceding, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", preceding)
+	case 1:
+		return fmt.Sprintf("%d", preceding+1)
+	}
+	return fmt.Sprintf("%d,%d", preceding+1, count)
+}
+
+// diffLines returns the shortest edit script that transforms the from lines in the to lines,
+// computed using the longest common subsequence of the lines
+func diffLines(from, to []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			lines = append(lines, diffLine{kind: ' ', text: from[i], from: i, to: j})
+			i++
+			j++
+		case j == len(to) || (i < len(from) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{kind: '-', text: from[i], from: i, to: j})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: to[j], from: i, to: j})
+			j++
+		}
+	}
+	return lines
+}
@@ -18,6 +18,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package utils_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/nerdzeu/nerdz-api/utils"
@@ -75,3 +76,48 @@ func TestUpperFirst(t *testing.T) {
 		t.Errorf("UpperFirst does not work")
 	}
 }
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := utils.UnifiedDiff("same", "same", "a", "b"); diff != "" {
+		t.Errorf("The diff of equal texts should be empty, got: %s", diff)
+	}
+
+	from := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve"
+	to := "zero\none\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\n10\neleven\ntwelve"
+	expected := `--- revision 1
++++ revision 2
+@@ -1,3 +1,4 @@
++zero
+ one
+ two
+ three
+@@ -7,6 +8,6 @@
+ seven
+ eight
+ nine
+-ten
++10
+ eleven
+ twelve
+`
+	if diff := utils.UnifiedDiff(from, to, "revision 1", "revision 2"); diff != expected {
+		t.Errorf("Expected diff:\n%s\nGot:\n%s", expected, diff)
+	}
+
+	expected = `--- a
++++ b
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+`
+	if diff := utils.UnifiedDiff("one\ntwo\nthree", "one\n2\nthree", "a", "b"); diff != expected {
+		t.Errorf("Expected diff:\n%s\nGot:\n%s", expected, diff)
+	}
+
+	long := strings.Repeat("line\n", utils.MaxDiffLines)
+	if diff := utils.UnifiedDiff(long, long+"one more", "a", "b"); diff != "" {
+		t.Errorf("The diff of texts longer than %d lines should be empty, got: %s", utils.MaxDiffLines, diff)
+	}
+}